@@ -2,7 +2,10 @@ package main
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"log"
+	"net/http"
 	"os"
 	"os/signal"
 	"syscall"
@@ -16,10 +19,16 @@ import (
 	"your_module_name/pkg/api"
 	"your_module_name/pkg/cache"
 	"your_module_name/pkg/database"
+	"your_module_name/pkg/httpclient"
+	"your_module_name/pkg/lifecycle"
+	"your_module_name/pkg/mailer"
 	"your_module_name/pkg/messagequeue"
 	// Adicionar outros imports internos necessários (ex: handlers, services)
 )
 
+// defaultShutdownTimeout é usado quando server.shutdown_timeout não está configurado.
+const defaultShutdownTimeout = 15 * time.Second
+
 // @title Go Standard Project Layout API
 // @version 1.0
 // @description Exemplo de API seguindo o padrão Go Standard Project Layout.
@@ -51,26 +60,74 @@ func main() {
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
+	// Gerenciador de ciclo de vida: cada serviço registra seu hook de encerramento,
+	// executado em ordem inversa ao registro durante o graceful shutdown.
+	shutdownManager := lifecycle.NewManager()
+
 	// --- Inicialização dos Serviços ---
 
+	// Mailer (SMTP)
+	// Com mailer.from vazio, o envio de e-mails fica desativado.
+	// Criado antes da API para ser injetado no diagnóstico de e-mail (POST /admin/mailer/test).
+	var notificationMailer mailer.Mailer
+	if cfg.Mailer.From != "" {
+		templateMailer, err := mailer.NewTemplateMailer(mailer.NewTemplateMailerConfig{
+			From:          cfg.Mailer.From,
+			SubjectPrefix: cfg.Mailer.SubjectPrefix,
+			SMTPAddr:      cfg.Mailer.SMTPAddr,
+			SMTPUser:      cfg.Mailer.SMTPUser,
+			SMTPPass:      cfg.Mailer.SMTPPassword,
+		})
+		if err != nil {
+			log.Printf("Aviso: Erro ao inicializar o mailer: %v. A aplicação continuará sem envio de e-mails.", err)
+		} else {
+			notificationMailer = templateMailer
+		}
+	}
+
 	// API (Gin)
 	// A instância GinService é criada. As rotas serão registradas dentro do método Run.
 	// Se precisar passar dependências para os handlers da API (como outros serviços),
-	// você pode modificar NewGinService para aceitá-las ou criar métodos setters.
-	apiService := api.NewGinService()
+	// você pode adicioná-las em NewGinServiceConfig ou criar métodos setters.
+	apiService, err := api.NewGinService(api.NewGinServiceConfig{
+		AdminAllowedCIDRs:  cfg.Admin.AllowedCIDRs,
+		TrustedProxies:     cfg.Server.TrustedProxies,
+		MaxBodyBytes:       cfg.Server.MaxBodyBytes,
+		BodyLimitOverrides: cfg.Server.BodyLimitOverrides,
+		LogErrorBodies:     cfg.Server.LogErrorBodies,
+		Mailer:             notificationMailer,
+	})
+	if err != nil {
+		log.Fatalf("Erro fatal ao inicializar a API: %v", err)
+	}
+	apiService.SetMaintenanceMode(cfg.Maintenance.Enabled, cfg.Maintenance.RetryAfter)
 
 	// Cache (Redis)
 	redisCache, err := cache.NewRedisCache(cache.NewRedisCacheConfig{
-		Address:  cfg.Redis.Address,
-		Password: cfg.Redis.Password,
-		DB:       cfg.Redis.DB,
+		Address:      cfg.Redis.Address,
+		Password:     cfg.Redis.Password,
+		DB:           cfg.Redis.DB,
+		PoolSize:     cfg.Redis.PoolSize,
+		DialTimeout:  cfg.Redis.DialTimeout,
+		ReadTimeout:  cfg.Redis.ReadTimeout,
+		WriteTimeout: cfg.Redis.WriteTimeout,
+		MaxRetries:   cfg.Redis.MaxRetries,
+		UseTLS:       cfg.Redis.UseTLS,
+		KeyPrefix:    cfg.Redis.KeyPrefix,
+		DefaultTTL:   cfg.Redis.DefaultTTL,
 	})
 	if err != nil {
 		log.Printf("Aviso: Erro ao inicializar Redis: %v. A aplicação continuará sem cache.", err)
 		// Decida se o erro de cache é fatal ou não. Aqui, estamos tratando como não fatal.
 		// Em um cenário real, você pode querer que seja fatal: log.Fatalf(...)
+		// O /readyz permanece em 503 para que a instância não receba tráfego sem o Redis.
+		apiService.AddReadinessCheck("redis", failedReadinessCheck("redis", err))
 	} else {
 		log.Println("Cache Redis conectado com sucesso.")
+		shutdownManager.Register("redis", func(ctx context.Context) error {
+			return redisCache.Close()
+		})
+		apiService.AddReadinessCheck("redis", redisCache.Ping)
 		// Exemplo de uso do cache:
 		if err := redisCache.Set("startup_key", "Redis conectado!", 1*time.Hour); err != nil {
 			log.Printf("Erro ao testar SET no cache Redis: %v", err)
@@ -86,14 +143,22 @@ func main() {
 	// Database (Firestore)
 	var firestoreService database.FirestoreDB // Use a interface
 	firestoreService, err = database.NewFirestoreService(ctx, database.NewFirestoreServiceConfig{
-		ProjectID:       cfg.Firestore.ProjectID,
-		CredentialsFile: cfg.Firestore.CredentialsFile,
+		ProjectID:        cfg.Firestore.ProjectID,
+		CredentialsFile:  cfg.Firestore.CredentialsFile,
+		CollectionPrefix: cfg.Firestore.CollectionPrefix,
+		ReadEndpoint:     cfg.Firestore.ReadEndpoint,
 	})
 	if err != nil {
 		log.Printf("Aviso: Erro ao inicializar Firestore: %v. A aplicação continuará sem banco de dados.", err)
 		// Tratar como não fatal por enquanto.
+		// O /readyz permanece em 503 para que a instância não receba tráfego sem o Firestore.
+		apiService.AddReadinessCheck("firestore", failedReadinessCheck("firestore", err))
 	} else {
 		log.Println("Banco de dados Firestore conectado com sucesso.")
+		shutdownManager.Register("firestore", func(ctx context.Context) error {
+			return firestoreService.Close()
+		})
+		apiService.AddReadinessCheck("firestore", firestoreService.Ping)
 		// Exemplo de uso do Firestore:
 		// docID, errAdd := firestoreService.Add(ctx, "test_collection", map[string]interface{}{"init_time": time.Now()})
 		// if errAdd != nil {
@@ -113,13 +178,9 @@ func main() {
 		// Tratar como não fatal por enquanto.
 	} else {
 		log.Println("RabbitMQ conectado com sucesso.")
-		// Garante que a conexão com RabbitMQ seja fechada ao final da main
-		defer func() {
-			log.Println("Fechando conexão com RabbitMQ...")
-			if err := mqService.Close(); err != nil {
-				log.Printf("Erro ao fechar conexão com RabbitMQ: %v", err)
-			}
-		}()
+		shutdownManager.Register("rabbitmq", func(ctx context.Context) error {
+			return mqService.Close()
+		})
 		// Exemplo de publicação no RabbitMQ:
 		// if err := mqService.Publish(cfg.RabbitMQ.QueueName, []byte("Servidor iniciado!")); err != nil {
 		// 	log.Printf("Erro ao publicar mensagem de inicialização no RabbitMQ: %v", err)
@@ -128,24 +189,74 @@ func main() {
 		// }
 	}
 
-	// TODO: Injetar as dependências (redisCache, firestoreService, mqService) nos handlers/serviços da API
-	// Por exemplo, se GinService tiver um método para registrar handlers que aceitam estas dependências:
-	// apiService.RegisterApplicationHandlers(firestoreService, redisCache, mqService)
+	// Cliente HTTP de saída (Stripe, webhooks...)
+	// Compartilhado por todos os serviços que chamam APIs externas, no lugar de http.DefaultClient,
+	// que não tem timeout: um serviço externo travado não consegue prender goroutines indefinidamente.
+	outboundClient, err := httpclient.NewHTTPClient(httpclient.NewHTTPClientConfig{
+		Timeout:               cfg.OutboundHTTP.Timeout,
+		DialTimeout:           cfg.OutboundHTTP.DialTimeout,
+		TLSHandshakeTimeout:   cfg.OutboundHTTP.TLSHandshakeTimeout,
+		ResponseHeaderTimeout: cfg.OutboundHTTP.ResponseHeaderTimeout,
+		IdleConnTimeout:       cfg.OutboundHTTP.IdleConnTimeout,
+		MaxIdleConns:          cfg.OutboundHTTP.MaxIdleConns,
+		MaxIdleConnsPerHost:   cfg.OutboundHTTP.MaxIdleConnsPerHost,
+		ProxyURL:              cfg.OutboundHTTP.ProxyURL,
+	})
+	if err != nil {
+		log.Fatalf("Erro fatal ao inicializar o cliente HTTP de saída: %v", err)
+	}
+	shutdownManager.Register("outbound-http", func(ctx context.Context) error {
+		outboundClient.CloseIdleConnections()
+		return nil
+	})
 
+	// TODO: Injetar as dependências (redisCache, firestoreService, mqService, notificationMailer, outboundClient) nos handlers/serviços da API
+	// Por exemplo, se GinService tiver um método para registrar handlers que aceitam estas dependências:
+	// apiService.RegisterApplicationHandlers(firestoreService, redisCache, mqService, notificationMailer)
 
 	// --- Inicialização do Servidor HTTP ---
 	// Goroutine para iniciar o servidor HTTP para não bloquear o canal de shutdown
 	serverAddress := cfg.Server.Host + ":" + cfg.Server.Port
+	// Registrado após as dependências para ser encerrado primeiro: as requisições em
+	// andamento terminam antes do fechamento das conexões com Redis, Firestore e RabbitMQ.
+	shutdownManager.Register("http-server", apiService.Shutdown)
+	// Registrado após o servidor HTTP para rodar antes dele: com o /readyz já retornando 503,
+	// aguarda server.drain_delay para que os load balancers parem de enviar tráfego.
+	shutdownManager.Register("drain", lifecycle.Delay(cfg.Server.DrainDelay))
 	go func() {
 		log.Printf("Iniciando servidor HTTP em %s", serverAddress)
 		// O método Run do GinService já registra as rotas (incluindo Swagger) e inicia o servidor.
-		if err := apiService.Run(serverAddress); err != nil {
-			// Não usar log.Fatalf aqui porque pode ser um erro normal de desligamento
+		if err := apiService.Run(serverAddress); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			// Não usar log.Fatalf aqui para permitir que o graceful shutdown seja executado
 			log.Printf("Erro ao iniciar ou durante execução do servidor HTTP: %v", err)
 		}
 		log.Println("Servidor HTTP finalizado.")
 	}()
 
+	// --- Recarga de Configuração (SIGHUP) ---
+	// Relê o arquivo de configuração sem reiniciar a aplicação. Apenas configurações
+	// não críticas (allowlist de admin e modo de manutenção) são aplicadas; as demais exigem restart.
+	reloadChannel := make(chan os.Signal, 1)
+	signal.Notify(reloadChannel, syscall.SIGHUP)
+	shutdownManager.Register("config-reload", func(ctx context.Context) error {
+		signal.Stop(reloadChannel)
+		close(reloadChannel)
+		return nil
+	})
+	go func() {
+		for range reloadChannel {
+			log.Println("Sinal SIGHUP recebido. Recarregando configuração...")
+			newCfg, err := configs.ReloadConfig()
+			if err != nil {
+				log.Printf("Erro ao recarregar configuração. Mantendo a configuração atual: %v", err)
+				continue
+			}
+			if err := apiService.SetAdminAllowlist(newCfg.Admin.AllowedCIDRs); err != nil {
+				log.Printf("Erro ao aplicar allowlist de admin recarregada: %v", err)
+			}
+			apiService.SetMaintenanceMode(newCfg.Maintenance.Enabled, newCfg.Maintenance.RetryAfter)
+		}
+	}()
 
 	// --- Graceful Shutdown ---
 	log.Println("Aplicação iniciada com sucesso. Pressione CTRL+C para sair.")
@@ -158,23 +269,20 @@ func main() {
 	sig := <-quitChannel
 	log.Printf("Sinal de interrupção recebido: %s. Iniciando graceful shutdown...", sig)
 
-	// Aqui você pode adicionar um contexto com timeout para o shutdown, se necessário.
-	// shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), 15*time.Second)
-	// defer shutdownCancel()
-
-	// Lógica de graceful shutdown para outros serviços, se houver.
-	// Por exemplo, se o apiService tivesse um método Shutdown:
-	// if hasattr(apiService, "Shutdown") { apiService.Shutdown(shutdownCtx) }
-
-	// O defer para mqService.Close() já está configurado.
-	// Se o Firestore client precisar ser fechado explicitamente (geralmente não é necessário para operações de curta duração):
-	if firestoreService != nil {
-		if fsImpl, ok := firestoreService.(*database.FirestoreService); ok {
-			log.Println("Fechando cliente Firestore...")
-			if err := fsImpl.Close(); err != nil {
-				log.Printf("Erro ao fechar cliente Firestore: %v", err)
-			}
-		}
+	// Sinaliza o readiness probe (/readyz) como indisponível para que os load balancers
+	// parem de enviar tráfego para esta instância antes do fechamento das conexões.
+	apiService.SetReady(false)
+
+	shutdownTimeout := cfg.Server.ShutdownTimeout
+	if shutdownTimeout <= 0 {
+		shutdownTimeout = defaultShutdownTimeout
+	}
+	shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer shutdownCancel()
+
+	// Executa os hooks registrados (servidor HTTP, RabbitMQ, Firestore, Redis...) em ordem inversa.
+	if err := shutdownManager.Shutdown(shutdownCtx); err != nil {
+		log.Printf("Erros durante o graceful shutdown: %v", err)
 	}
 
 	// Cancelar o contexto principal para sinalizar outras goroutines
@@ -182,3 +290,11 @@ func main() {
 
 	log.Println("Aplicação finalizada.")
 }
+
+// failedReadinessCheck retorna uma verificação que sempre falha com o erro de inicialização
+// da dependência, mantendo o /readyz em 503 quando ela não pôde ser conectada.
+func failedReadinessCheck(name string, err error) api.ReadinessCheck {
+	return func(ctx context.Context) error {
+		return fmt.Errorf("%s: initialization failed: %w", name, err)
+	}
+}
@@ -1,33 +1,141 @@
 package configs
 
 import (
-	"os"
+	"errors"
+	"fmt"
 	"log"
+	"net"
+	"net/mail"
+	"net/url"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+	"unicode/utf8"
 
 	"gopkg.in/yaml.v3"
 )
 
+// current holds the active configuration. It is swapped atomically on reload.
+var current atomic.Pointer[Config]
+
 type Config struct {
 	Server struct {
-		Port string `yaml:"port"`
-		Host string `yaml:"host"`
+		Port               string           `yaml:"port"`
+		Host               string           `yaml:"host"`
+		TrustedProxies     []string         `yaml:"trusted_proxies"`
+		LogErrorBodies     bool             `yaml:"log_error_bodies"`
+		ShutdownTimeout    time.Duration    `yaml:"shutdown_timeout"`
+		DrainDelay         time.Duration    `yaml:"drain_delay"`
+		MaxBodyBytes       int64            `yaml:"max_body_bytes"`
+		BodyLimitOverrides map[string]int64 `yaml:"body_limit_overrides"`
 	} `yaml:"server"`
+	Admin struct {
+		AllowedCIDRs []string `yaml:"allowed_cidrs"`
+	} `yaml:"admin"`
+	Maintenance struct {
+		Enabled    bool          `yaml:"enabled"`
+		RetryAfter time.Duration `yaml:"retry_after"`
+	} `yaml:"maintenance"`
 	Redis struct {
-		Address  string `yaml:"address"`
-		Password string `yaml:"password"`
-		DB       int    `yaml:"db"`
+		Address      string        `yaml:"address"`
+		Password     string        `yaml:"password"`
+		DB           int           `yaml:"db"`
+		PoolSize     int           `yaml:"pool_size"`
+		DialTimeout  time.Duration `yaml:"dial_timeout"`
+		ReadTimeout  time.Duration `yaml:"read_timeout"`
+		WriteTimeout time.Duration `yaml:"write_timeout"`
+		MaxRetries   int           `yaml:"max_retries"`
+		UseTLS       bool          `yaml:"use_tls"`
+		KeyPrefix    string        `yaml:"key_prefix"`
+		DefaultTTL   time.Duration `yaml:"default_ttl"`
 	} `yaml:"redis"`
 	Firestore struct {
-		ProjectID      string `yaml:"project_id"`
-		CredentialsFile string `yaml:"credentials_file"`
+		ProjectID        string `yaml:"project_id"`
+		CredentialsFile  string `yaml:"credentials_file"`
+		CollectionPrefix string `yaml:"collection_prefix"`
+		ReadEndpoint     string `yaml:"read_endpoint"`
 	} `yaml:"firestore"`
 	RabbitMQ struct {
-		URL      string `yaml:"url"`
+		URL       string `yaml:"url"`
 		QueueName string `yaml:"queue_name"`
 	} `yaml:"rabbitmq"`
+	OutboundHTTP struct {
+		Timeout               time.Duration `yaml:"timeout"`
+		DialTimeout           time.Duration `yaml:"dial_timeout"`
+		TLSHandshakeTimeout   time.Duration `yaml:"tls_handshake_timeout"`
+		ResponseHeaderTimeout time.Duration `yaml:"response_header_timeout"`
+		IdleConnTimeout       time.Duration `yaml:"idle_conn_timeout"`
+		MaxIdleConns          int           `yaml:"max_idle_conns"`
+		MaxIdleConnsPerHost   int           `yaml:"max_idle_conns_per_host"`
+		ProxyURL              string        `yaml:"proxy_url"`
+	} `yaml:"outbound_http"`
+	Mailer struct {
+		From          string `yaml:"from"`
+		SubjectPrefix string `yaml:"subject_prefix"`
+		SMTPAddr      string `yaml:"smtp_addr"`
+		SMTPUser      string `yaml:"smtp_user"`
+		SMTPPassword  string `yaml:"smtp_password"`
+	} `yaml:"mailer"`
 }
 
+// LoadConfig reads the configuration file and makes it the active configuration.
 func LoadConfig() (*Config, error) {
+	cfg, err := readConfig()
+	if err != nil {
+		return nil, err
+	}
+	current.Store(cfg)
+	return cfg, nil
+}
+
+// GetConfig returns the active configuration, or nil if LoadConfig has not succeeded yet.
+// Callers that need to observe reloads should call it instead of holding on to a *Config.
+func GetConfig() *Config {
+	return current.Load()
+}
+
+// ReloadConfig re-reads the configuration file and atomically swaps in the settings
+// that can change at runtime (currently the admin and maintenance sections).
+// Sections used to establish connections at startup (server, redis, firestore, rabbitmq,
+// outbound_http, mailer)
+// keep their previous values; changes to them are logged and ignored until a restart.
+func ReloadConfig() (*Config, error) {
+	prev := current.Load()
+	if prev == nil {
+		return LoadConfig()
+	}
+
+	next, err := readConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	keepImmutable("server", &prev.Server, &next.Server)
+	keepImmutable("redis", &prev.Redis, &next.Redis)
+	keepImmutable("firestore", &prev.Firestore, &next.Firestore)
+	keepImmutable("rabbitmq", &prev.RabbitMQ, &next.RabbitMQ)
+	keepImmutable("outbound_http", &prev.OutboundHTTP, &next.OutboundHTTP)
+	keepImmutable("mailer", &prev.Mailer, &next.Mailer)
+
+	current.Store(next)
+	log.Println("Configuration reloaded")
+	return next, nil
+}
+
+// keepImmutable copies the previous value of a section into the new config,
+// warning when the file tried to change it.
+func keepImmutable[T any](section string, prev, next *T) {
+	if !reflect.DeepEqual(*prev, *next) {
+		log.Printf("Warning: changes to the %q config section require a restart and were ignored", section)
+	}
+	*next = *prev
+}
+
+// readConfig reads and parses the configuration file pointed to by PATH_CONFIG.
+func readConfig() (*Config, error) {
 	var cfg Config
 	configPath := os.Getenv("PATH_CONFIG")
 	if configPath == "" {
@@ -48,5 +156,133 @@ func LoadConfig() (*Config, error) {
 		return nil, err
 	}
 
+	if err := cfg.Validate(); err != nil {
+		log.Printf("Invalid configuration in %s: %v", configPath, err)
+		return nil, err
+	}
+
 	return &cfg, nil
 }
+
+// Validate checks the whole configuration and reports every missing or invalid
+// field at once, so operators can fix them all in a single pass.
+func (c *Config) Validate() error {
+	var errs []error
+	addErr := func(format string, args ...interface{}) {
+		errs = append(errs, fmt.Errorf(format, args...))
+	}
+
+	if c.Server.Port == "" {
+		addErr("server.port is required")
+	} else if port, err := strconv.Atoi(c.Server.Port); err != nil || port < 1 || port > 65535 {
+		addErr("server.port %q must be a number between 1 and 65535", c.Server.Port)
+	}
+	for _, entry := range c.Server.TrustedProxies {
+		if !isIPOrCIDR(entry) {
+			addErr("server.trusted_proxies entry %q is not a valid IP or CIDR", entry)
+		}
+	}
+	if c.Server.ShutdownTimeout < 0 {
+		addErr("server.shutdown_timeout must not be negative")
+	}
+	if c.Server.DrainDelay < 0 {
+		addErr("server.drain_delay must not be negative")
+	}
+	if c.Server.MaxBodyBytes < 0 {
+		addErr("server.max_body_bytes must not be negative")
+	}
+	for prefix, limit := range c.Server.BodyLimitOverrides {
+		if !strings.HasPrefix(prefix, "/") || limit <= 0 {
+			addErr("server.body_limit_overrides entry %q must be a path starting with / and a positive limit", prefix)
+		}
+	}
+
+	if c.Maintenance.RetryAfter < 0 {
+		addErr("maintenance.retry_after must not be negative")
+	}
+
+	for _, entry := range c.Admin.AllowedCIDRs {
+		if !isIPOrCIDR(entry) {
+			addErr("admin.allowed_cidrs entry %q is not a valid IP or CIDR", entry)
+		}
+	}
+
+	if c.Redis.Address != "" {
+		if _, _, err := net.SplitHostPort(c.Redis.Address); err != nil {
+			addErr("redis.address %q must be in host:port form", c.Redis.Address)
+		}
+	}
+	if c.Redis.DB < 0 {
+		addErr("redis.db must not be negative")
+	}
+	if c.Redis.PoolSize < 0 {
+		addErr("redis.pool_size must not be negative")
+	}
+	if c.Redis.DialTimeout < 0 || c.Redis.ReadTimeout < 0 || c.Redis.WriteTimeout < 0 {
+		addErr("redis.dial_timeout, redis.read_timeout and redis.write_timeout must not be negative")
+	}
+	if c.Redis.MaxRetries < -1 {
+		addErr("redis.max_retries must be -1 (disabled) or greater")
+	}
+	if c.Redis.DefaultTTL < 0 {
+		addErr("redis.default_ttl must not be negative")
+	}
+
+	if prefix := c.Firestore.CollectionPrefix; prefix != "" && !isCollectionIDPrefix(prefix) {
+		addErr("firestore.collection_prefix %q must be valid UTF-8 without \"/\" and must not start with \"__\"", prefix)
+	}
+
+	if c.RabbitMQ.URL != "" {
+		if u, err := url.Parse(c.RabbitMQ.URL); err != nil || (u.Scheme != "amqp" && u.Scheme != "amqps") {
+			addErr("rabbitmq.url must be an amqp:// or amqps:// URL")
+		}
+	}
+
+	outbound := c.OutboundHTTP
+	if outbound.Timeout < 0 || outbound.DialTimeout < 0 || outbound.TLSHandshakeTimeout < 0 ||
+		outbound.ResponseHeaderTimeout < 0 || outbound.IdleConnTimeout < 0 {
+		addErr("outbound_http timeouts must not be negative")
+	}
+	if outbound.MaxIdleConns < 0 || outbound.MaxIdleConnsPerHost < 0 {
+		addErr("outbound_http.max_idle_conns and outbound_http.max_idle_conns_per_host must not be negative")
+	}
+	if outbound.ProxyURL != "" {
+		if u, err := url.Parse(outbound.ProxyURL); err != nil || u.Scheme == "" || u.Host == "" {
+			addErr("outbound_http.proxy_url %q must be an absolute URL", outbound.ProxyURL)
+		}
+	}
+
+	if c.Mailer.From != "" {
+		// The value is also the SMTP envelope sender, which can't carry a display name.
+		if addr, err := mail.ParseAddress(c.Mailer.From); err != nil || addr.Address != c.Mailer.From {
+			addErr("mailer.from %q must be a bare email address such as noreply@example.com", c.Mailer.From)
+		}
+	}
+	if c.Mailer.SMTPAddr != "" {
+		if _, _, err := net.SplitHostPort(c.Mailer.SMTPAddr); err != nil {
+			addErr("mailer.smtp_addr %q must be in host:port form", c.Mailer.SMTPAddr)
+		}
+	}
+
+	if len(errs) == 0 {
+		return nil
+	}
+	return fmt.Errorf("%d configuration problem(s): %w", len(errs), errors.Join(errs...))
+}
+
+// isCollectionIDPrefix reports whether prefix can start a Firestore collection ID.
+// Collection IDs must be valid UTF-8 and cannot contain "/", and IDs matching __.*__
+// are reserved, so a prefix starting with "__" is rejected as well.
+func isCollectionIDPrefix(prefix string) bool {
+	return utf8.ValidString(prefix) && !strings.Contains(prefix, "/") && !strings.HasPrefix(prefix, "__")
+}
+
+// isIPOrCIDR reports whether entry is a single IP address or a CIDR block.
+func isIPOrCIDR(entry string) bool {
+	entry = strings.TrimSpace(entry)
+	if strings.Contains(entry, "/") {
+		_, _, err := net.ParseCIDR(entry)
+		return err == nil
+	}
+	return net.ParseIP(entry) != nil
+}
@@ -1,9 +1,38 @@
 package api
 
-import "github.com/gin-gonic/gin"
+import (
+	"context"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ErrorResponse is the JSON body returned for error responses.
+type ErrorResponse struct {
+	Error string `json:"error"`
+}
+
+// EmailSender sends templated emails. It is satisfied by mailer.Mailer.
+type EmailSender interface {
+	SendTemplate(name string, data interface{}, recipient string) error
+}
+
+// ReadinessCheck reports whether a dependency is reachable.
+// It should return nil when the dependency is ready to serve traffic.
+type ReadinessCheck func(ctx context.Context) error
 
 // API defines the interface for API services.
 type API interface {
 	RegisterRoutes(router *gin.Engine)
 	Run(addr string) error
+	// Shutdown gracefully stops the server started by Run.
+	Shutdown(ctx context.Context) error
+	// AddReadinessCheck registers a dependency check evaluated by the readiness probe.
+	AddReadinessCheck(name string, check ReadinessCheck)
+	// SetReady flips the readiness state, e.g. to drain traffic during shutdown.
+	SetReady(ready bool)
+	// SetAdminAllowlist replaces the networks allowed to reach admin routes.
+	SetAdminAllowlist(cidrs []string) error
+	// SetMaintenanceMode blocks or unblocks mutating requests outside the admin routes.
+	SetMaintenanceMode(enabled bool, retryAfter time.Duration)
 }
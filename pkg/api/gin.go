@@ -1,8 +1,15 @@
 package api
 
 import (
-	"fmt"
+	"context"
+	"errors"
 	"log"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	swaggerFiles "github.com/swaggo/files"
@@ -14,9 +21,42 @@ import (
 	_ "project-layout-template/docs" // Adjust this to your project's module path
 )
 
+// readinessCheckTimeout bounds how long the readiness probe waits on each dependency.
+const readinessCheckTimeout = 2 * time.Second
+
 // GinService is an implementation of the API interface using Gin.
 type GinService struct {
-	router *gin.Engine
+	router         *gin.Engine
+	adminAllowlist *IPAllowlist
+	maintenance    *MaintenanceMode
+	mailer         EmailSender
+
+	serverMu sync.Mutex
+	server   *http.Server
+
+	ready           atomic.Bool
+	checksMu        sync.RWMutex
+	readinessChecks map[string]ReadinessCheck
+}
+
+// adminPrefix is the path prefix of the admin route group.
+const adminPrefix = "/admin"
+
+// NewGinServiceConfig contains options for creating a new GinService.
+type NewGinServiceConfig struct {
+	// AdminAllowedCIDRs lists the networks allowed to call /admin routes. Empty denies all.
+	AdminAllowedCIDRs []string
+	// TrustedProxies lists the proxies whose X-Forwarded-For header is honoured by c.ClientIP(),
+	// and therefore by the admin IP allowlist. Empty trusts no proxy.
+	TrustedProxies []string
+	// MaxBodyBytes limits request body size. Zero uses DefaultMaxBodyBytes.
+	MaxBodyBytes int64
+	// BodyLimitOverrides maps path prefixes to a different body limit (e.g. bulk import routes).
+	BodyLimitOverrides map[string]int64
+	// LogErrorBodies enables logging of redacted request/response bodies for non-2xx responses.
+	LogErrorBodies bool
+	// Mailer is used by the admin email diagnostic. Nil makes it report the mailer as not configured.
+	Mailer EmailSender
 }
 
 // NewGinService creates a new GinService.
@@ -34,9 +74,71 @@ type GinService struct {
 
 // @host localhost:8080
 // @BasePath /
-func NewGinService() API {
+func NewGinService(cfg NewGinServiceConfig) (API, error) {
+	adminAllowlist, err := NewIPAllowlist(cfg.AdminAllowedCIDRs)
+	if err != nil {
+		log.Printf("Failed to build admin IP allowlist: %v", err)
+		return nil, err
+	}
+
 	r := gin.Default()
-	return &GinService{router: r}
+	// gin trusts every proxy by default, which lets any client spoof c.ClientIP() via
+	// X-Forwarded-For. Only trust the configured proxies; an empty list disables proxy trust
+	// and ClientIP falls back to the connection's remote address.
+	if err := r.SetTrustedProxies(cfg.TrustedProxies); err != nil {
+		log.Printf("Failed to configure trusted proxies: %v", err)
+		return nil, err
+	}
+	// Resolve ClientIP from X-Forwarded-For only; X-Real-IP carries a single, unverifiable hop.
+	r.RemoteIPHeaders = []string{"X-Forwarded-For"}
+	// Registered before any middleware that reads the body.
+	r.Use(MaxBodySize(cfg.MaxBodyBytes, cfg.BodyLimitOverrides))
+	// Admin routes stay writable so operators can finish the maintenance and turn it off.
+	maintenance := NewMaintenanceMode(adminPrefix)
+	r.Use(maintenance.Middleware())
+	if cfg.LogErrorBodies {
+		r.Use(BodyLogger())
+	}
+	s := &GinService{
+		router:          r,
+		adminAllowlist:  adminAllowlist,
+		maintenance:     maintenance,
+		mailer:          cfg.Mailer,
+		readinessChecks: make(map[string]ReadinessCheck),
+	}
+	// Answer wrong methods on existing paths with 405 instead of 404,
+	// and give both cases a structured body.
+	r.HandleMethodNotAllowed = true
+	r.NoMethod(s.handleMethodNotAllowed)
+	r.NoRoute(handleNotFound)
+	s.ready.Store(true)
+	return s, nil
+}
+
+// AddReadinessCheck registers a dependency check evaluated by /readyz.
+func (s *GinService) AddReadinessCheck(name string, check ReadinessCheck) {
+	s.checksMu.Lock()
+	defer s.checksMu.Unlock()
+	s.readinessChecks[name] = check
+}
+
+// SetReady sets whether the service accepts traffic.
+// Setting it to false makes /readyz return 503 so load balancers drain the instance.
+func (s *GinService) SetReady(ready bool) {
+	s.ready.Store(ready)
+}
+
+// SetAdminAllowlist replaces the networks allowed to reach /admin routes.
+func (s *GinService) SetAdminAllowlist(cidrs []string) error {
+	return s.adminAllowlist.SetAllowed(cidrs)
+}
+
+// SetMaintenanceMode blocks or unblocks mutating requests outside the admin routes.
+func (s *GinService) SetMaintenanceMode(enabled bool, retryAfter time.Duration) {
+	if enabled != s.maintenance.Enabled() {
+		log.Printf("Maintenance mode set to %t", enabled)
+	}
+	s.maintenance.Set(enabled, retryAfter)
 }
 
 // RegisterRoutes registers application routes.
@@ -55,10 +157,37 @@ func (s *GinService) RegisterRoutes(router *gin.Engine) {
 		})
 	})
 
+	// Liveness probe route
+	// @Summary Liveness probe.
+	// @Description returns 200 while the process is up. It does not check dependencies.
+	// @Tags Health
+	// @Produce json
+	// @Success 200 {object} map[string]interface{}
+	// @Router /healthz [get]
+	router.GET("/healthz", func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{
+			"status": "UP",
+		})
+	})
+
+	// Readiness probe route
+	// @Summary Readiness probe.
+	// @Description returns 200 when all registered dependencies are reachable, 503 otherwise or while shutting down.
+	// @Tags Health
+	// @Produce json
+	// @Success 200 {object} map[string]interface{}
+	// @Failure 503 {object} map[string]interface{}
+	// @Router /readyz [get]
+	router.GET("/readyz", s.handleReadiness)
+
 	// Swagger documentation route
 	// url := ginSwagger.URL("/swagger/doc.json") // The url pointing to API definition
 	router.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
 
+	// Admin routes, restricted to the networks configured in admin.allowed_cidrs.
+	admin := router.Group(adminPrefix, s.adminAllowlist.Middleware())
+	s.registerAdminRoutes(admin)
+
 	// Example of a versioned API group
 	// v1 := router.Group("/v1")
 	// {
@@ -66,12 +195,263 @@ func (s *GinService) RegisterRoutes(router *gin.Engine) {
 	// }
 }
 
+// registerAdminRoutes registers operational routes under the admin group.
+// Every route added here inherits the admin IP allowlist.
+func (s *GinService) registerAdminRoutes(admin *gin.RouterGroup) {
+	// Maintenance mode status
+	// @Summary Show maintenance mode status.
+	// @Tags Admin
+	// @Produce json
+	// @Success 200 {object} maintenanceStatus
+	// @Router /admin/maintenance [get]
+	admin.GET("/maintenance", s.handleGetMaintenance)
+
+	// Maintenance mode toggle
+	// @Summary Enable or disable maintenance mode.
+	// @Description While enabled, POST/PUT/PATCH/DELETE requests outside /admin return 503 with Retry-After.
+	// @Tags Admin
+	// @Accept json
+	// @Produce json
+	// @Param request body maintenanceStatus true "Desired maintenance state"
+	// @Success 200 {object} maintenanceStatus
+	// @Failure 400 {object} ErrorResponse
+	// @Router /admin/maintenance [put]
+	admin.PUT("/maintenance", s.handleSetMaintenance)
+
+	// Mailer diagnostic
+	// @Summary Send a test email.
+	// @Description Sends the test_email template to the given recipient to verify the SMTP configuration.
+	// @Tags Admin
+	// @Accept json
+	// @Produce json
+	// @Param request body mailerTestRequest true "Recipient of the test email"
+	// @Success 200 {object} mailerTestResponse
+	// @Failure 400 {object} ErrorResponse
+	// @Failure 502 {object} ErrorResponse
+	// @Failure 503 {object} ErrorResponse
+	// @Router /admin/mailer/test [post]
+	admin.POST("/mailer/test", s.handleMailerTest)
+}
+
+// maintenanceStatus is the request and response body of the maintenance admin routes.
+type maintenanceStatus struct {
+	Enabled           *bool `json:"enabled" binding:"required"`
+	RetryAfterSeconds int   `json:"retryAfterSeconds"`
+}
+
+func (s *GinService) maintenanceStatus() maintenanceStatus {
+	enabled := s.maintenance.Enabled()
+	return maintenanceStatus{
+		Enabled:           &enabled,
+		RetryAfterSeconds: int(s.maintenance.RetryAfter() / time.Second),
+	}
+}
+
+func (s *GinService) handleGetMaintenance(c *gin.Context) {
+	c.JSON(http.StatusOK, s.maintenanceStatus())
+}
+
+func (s *GinService) handleSetMaintenance(c *gin.Context) {
+	var req maintenanceStatus
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondBindError(c, err)
+		return
+	}
+	if req.RetryAfterSeconds < 0 {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error: "retryAfterSeconds must not be negative",
+		})
+		return
+	}
+	s.SetMaintenanceMode(*req.Enabled, time.Duration(req.RetryAfterSeconds)*time.Second)
+	c.JSON(http.StatusOK, s.maintenanceStatus())
+}
+
+// testEmailTemplate is the mailer template sent by the email diagnostic.
+const testEmailTemplate = "test_email"
+
+// mailerTestRequest is the request body of the email diagnostic route.
+type mailerTestRequest struct {
+	Recipient string `json:"recipient" binding:"required,email"`
+}
+
+// mailerTestResponse is the response body of a successful email diagnostic.
+type mailerTestResponse struct {
+	Status    string `json:"status"`
+	Recipient string `json:"recipient"`
+}
+
+func (s *GinService) handleMailerTest(c *gin.Context) {
+	var req mailerTestRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondBindError(c, err)
+		return
+	}
+	if s.mailer == nil {
+		c.JSON(http.StatusServiceUnavailable, ErrorResponse{
+			Error: "mailer is not configured",
+		})
+		return
+	}
+
+	data := map[string]string{
+		"SentAt": time.Now().UTC().Format(time.RFC3339),
+	}
+	if err := s.mailer.SendTemplate(testEmailTemplate, data, req.Recipient); err != nil {
+		log.Printf("Test email to %s failed: %v", req.Recipient, err)
+		// The SMTP error is returned as is: it is the point of the diagnostic.
+		c.JSON(http.StatusBadGateway, ErrorResponse{
+			Error: err.Error(),
+		})
+		return
+	}
+
+	log.Printf("Test email sent to %s", req.Recipient)
+	c.JSON(http.StatusOK, mailerTestResponse{
+		Status:    "sent",
+		Recipient: req.Recipient,
+	})
+}
+
+// respondBindError answers a request whose body could not be bound. Bodies cut off by
+// MaxBodySize get 413, like oversized bodies rejected on their Content-Length; anything
+// else is a malformed request.
+func respondBindError(c *gin.Context, err error) {
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		c.JSON(http.StatusRequestEntityTooLarge, ErrorResponse{
+			Error: "request body too large",
+		})
+		return
+	}
+	c.JSON(http.StatusBadRequest, ErrorResponse{
+		Error: "invalid request body: " + err.Error(),
+	})
+}
+
+// handleMethodNotAllowed answers requests whose path exists but whose method doesn't.
+// OPTIONS requests (e.g. CORS preflight) get 204 with the Allow header instead of an error.
+func (s *GinService) handleMethodNotAllowed(c *gin.Context) {
+	// Older gin versions don't set Allow, and newer ones omit OPTIONS, so always build it here.
+	c.Header("Allow", s.allowedMethods(c.Request.URL.Path))
+	if c.Request.Method == http.MethodOptions {
+		c.AbortWithStatus(http.StatusNoContent)
+		return
+	}
+	c.AbortWithStatusJSON(http.StatusMethodNotAllowed, ErrorResponse{
+		Error: "method " + c.Request.Method + " not allowed on " + c.Request.URL.Path,
+	})
+}
+
+// allowedMethods returns the Allow header value for path: the sorted methods of every
+// registered route matching it, followed by OPTIONS.
+func (s *GinService) allowedMethods(path string) string {
+	seen := map[string]bool{http.MethodOptions: true}
+	var methods []string
+	for _, route := range s.router.Routes() {
+		if !seen[route.Method] && routeMatches(route.Path, path) {
+			seen[route.Method] = true
+			methods = append(methods, route.Method)
+		}
+	}
+	sort.Strings(methods)
+	return strings.Join(append(methods, http.MethodOptions), ", ")
+}
+
+// routeMatches reports whether path matches a gin route pattern,
+// where ":name" matches one segment and "*name" matches the rest of the path.
+func routeMatches(pattern, path string) bool {
+	patternParts := strings.Split(strings.Trim(pattern, "/"), "/")
+	pathParts := strings.Split(strings.Trim(path, "/"), "/")
+	for i, part := range patternParts {
+		if strings.HasPrefix(part, "*") {
+			return true
+		}
+		if i >= len(pathParts) {
+			return false
+		}
+		if strings.HasPrefix(part, ":") {
+			if pathParts[i] == "" {
+				return false
+			}
+			continue
+		}
+		if part != pathParts[i] {
+			return false
+		}
+	}
+	return len(patternParts) == len(pathParts)
+}
+
+// handleNotFound answers requests that match no route.
+func handleNotFound(c *gin.Context) {
+	c.AbortWithStatusJSON(http.StatusNotFound, ErrorResponse{
+		Error: "route " + c.Request.URL.Path + " not found",
+	})
+}
+
+// handleReadiness runs the registered readiness checks and reports the aggregated state.
+func (s *GinService) handleReadiness(c *gin.Context) {
+	if !s.ready.Load() {
+		c.JSON(http.StatusServiceUnavailable, gin.H{
+			"status": "SHUTTING_DOWN",
+		})
+		return
+	}
+
+	s.checksMu.RLock()
+	defer s.checksMu.RUnlock()
+
+	status := http.StatusOK
+	checks := make(map[string]string, len(s.readinessChecks))
+	for name, check := range s.readinessChecks {
+		ctx, cancel := context.WithTimeout(c.Request.Context(), readinessCheckTimeout)
+		err := check(ctx)
+		cancel()
+		if err != nil {
+			log.Printf("Readiness check %s failed: %v", name, err)
+			checks[name] = "DOWN"
+			status = http.StatusServiceUnavailable
+			continue
+		}
+		checks[name] = "UP"
+	}
+
+	overall := "UP"
+	if status != http.StatusOK {
+		overall = "DOWN"
+	}
+	c.JSON(status, gin.H{
+		"status": overall,
+		"checks": checks,
+	})
+}
+
 // Run starts the Gin HTTP server.
 func (s *GinService) Run(addr string) error {
 	s.RegisterRoutes(s.router) // Register routes before running
 	log.Printf("Gin server starting on %s", addr)
 	log.Printf("Swagger documentation available at http://%s/swagger/index.html", addr)
-	return s.router.Run(addr)
+
+	srv := &http.Server{Addr: addr, Handler: s.router}
+	s.serverMu.Lock()
+	s.server = srv
+	s.serverMu.Unlock()
+
+	return srv.ListenAndServe()
+}
+
+// Shutdown gracefully stops the HTTP server, waiting for in-flight requests until ctx is done.
+// Run returns http.ErrServerClosed once Shutdown is called.
+func (s *GinService) Shutdown(ctx context.Context) error {
+	s.serverMu.Lock()
+	srv := s.server
+	s.serverMu.Unlock()
+	if srv == nil {
+		return nil
+	}
+	log.Println("Gin server shutting down")
+	return srv.Shutdown(ctx)
 }
 
 // GetRouter returns the underlying Gin router.
@@ -0,0 +1,270 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"your_module_name/pkg/mailer"
+)
+
+func TestMain(m *testing.M) {
+	gin.SetMode(gin.TestMode)
+	os.Exit(m.Run())
+}
+
+// newTestService builds a GinService with its routes registered.
+// httptest requests come from 192.0.2.1, so allow it on the admin routes unless cfg says otherwise.
+func newTestService(t *testing.T, cfg NewGinServiceConfig) *GinService {
+	t.Helper()
+	if cfg.AdminAllowedCIDRs == nil {
+		cfg.AdminAllowedCIDRs = []string{"192.0.2.1/32"}
+	}
+	svc, err := NewGinService(cfg)
+	if err != nil {
+		t.Fatalf("NewGinService() error = %v", err)
+	}
+	s := svc.(*GinService)
+	s.RegisterRoutes(s.router)
+	return s
+}
+
+// serve runs req through the service router and returns the recorded response.
+func serve(s *GinService, req *http.Request) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	s.router.ServeHTTP(w, req)
+	return w
+}
+
+func TestReadinessTransitions(t *testing.T) {
+	s := newTestService(t, NewGinServiceConfig{})
+	var redisErr error
+	s.AddReadinessCheck("redis", func(ctx context.Context) error {
+		if _, ok := ctx.Deadline(); !ok {
+			t.Error("readiness check ctx has no deadline")
+		}
+		return redisErr
+	})
+
+	readyz := func() (int, map[string]interface{}) {
+		w := serve(s, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+		var body map[string]interface{}
+		if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+			t.Fatalf("invalid /readyz body %q: %v", w.Body.String(), err)
+		}
+		return w.Code, body
+	}
+
+	code, body := readyz()
+	if code != http.StatusOK || body["status"] != "UP" {
+		t.Errorf("ready: got %d %v, want 200 UP", code, body)
+	}
+
+	redisErr = errors.New("connection refused")
+	code, body = readyz()
+	checks, _ := body["checks"].(map[string]interface{})
+	if code != http.StatusServiceUnavailable || body["status"] != "DOWN" || checks["redis"] != "DOWN" {
+		t.Errorf("dependency down: got %d %v, want 503 DOWN with redis DOWN", code, body)
+	}
+
+	redisErr = nil
+	code, _ = readyz()
+	if code != http.StatusOK {
+		t.Errorf("dependency recovered: got %d, want 200", code)
+	}
+
+	s.SetReady(false)
+	code, body = readyz()
+	if code != http.StatusServiceUnavailable || body["status"] != "SHUTTING_DOWN" {
+		t.Errorf("shutting down: got %d %v, want 503 SHUTTING_DOWN", code, body)
+	}
+
+	// Liveness does not depend on readiness.
+	if w := serve(s, httptest.NewRequest(http.MethodGet, "/healthz", nil)); w.Code != http.StatusOK {
+		t.Errorf("/healthz while shutting down: got %d, want 200", w.Code)
+	}
+}
+
+func TestClientIPWithTrustedProxies(t *testing.T) {
+	tests := []struct {
+		name           string
+		trustedProxies []string
+		remoteAddr     string
+		xff            string
+		want           string
+	}{
+		{"no proxy trusted ignores XFF", nil, "203.0.113.9:1234", "198.51.100.7", "203.0.113.9"},
+		{"untrusted peer ignores XFF", []string{"10.0.0.0/8"}, "203.0.113.9:1234", "198.51.100.7", "203.0.113.9"},
+		{"trusted proxy forwards client", []string{"10.0.0.0/8"}, "10.0.0.5:1234", "198.51.100.7", "198.51.100.7"},
+		{"spoofed entry left of real hop", []string{"10.0.0.0/8"}, "10.0.0.5:1234", "192.0.2.1, 198.51.100.7", "198.51.100.7"},
+		{"proxy chain skips trusted hops", []string{"10.0.0.0/8"}, "10.0.0.5:1234", "198.51.100.7, 10.0.0.6", "198.51.100.7"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newTestService(t, NewGinServiceConfig{TrustedProxies: tt.trustedProxies})
+			s.router.GET("/test/client-ip", func(c *gin.Context) {
+				c.String(http.StatusOK, c.ClientIP())
+			})
+
+			req := httptest.NewRequest(http.MethodGet, "/test/client-ip", nil)
+			req.RemoteAddr = tt.remoteAddr
+			req.Header.Set("X-Forwarded-For", tt.xff)
+			if got := serve(s, req).Body.String(); got != tt.want {
+				t.Errorf("ClientIP() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMethodNotAllowedAndNotFound(t *testing.T) {
+	s := newTestService(t, NewGinServiceConfig{})
+	s.router.GET("/test/items/:id", func(c *gin.Context) { c.Status(http.StatusOK) })
+	s.router.DELETE("/test/items/:id", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	tests := []struct {
+		name      string
+		method    string
+		path      string
+		wantCode  int
+		wantAllow string
+	}{
+		{"wrong method", http.MethodPost, "/healthz", http.StatusMethodNotAllowed, "GET, OPTIONS"},
+		{"wrong method on admin route", http.MethodPatch, "/admin/maintenance", http.StatusMethodNotAllowed, "GET, PUT, OPTIONS"},
+		{"wrong method on param route", http.MethodPut, "/test/items/42", http.StatusMethodNotAllowed, "DELETE, GET, OPTIONS"},
+		{"preflight", http.MethodOptions, "/healthz", http.StatusNoContent, "GET, OPTIONS"},
+		{"unknown path", http.MethodGet, "/does-not-exist", http.StatusNotFound, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := serve(s, httptest.NewRequest(tt.method, tt.path, nil))
+			if w.Code != tt.wantCode {
+				t.Fatalf("got %d, want %d", w.Code, tt.wantCode)
+			}
+			if got := w.Header().Get("Allow"); got != tt.wantAllow {
+				t.Errorf("Allow = %q, want %q", got, tt.wantAllow)
+			}
+			if w.Code == http.StatusNoContent {
+				return
+			}
+			var body ErrorResponse
+			if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil || body.Error == "" {
+				t.Errorf("body = %q, want an ErrorResponse", w.Body.String())
+			}
+		})
+	}
+}
+
+func TestRouteMatches(t *testing.T) {
+	tests := []struct {
+		pattern, path string
+		want          bool
+	}{
+		{"/healthz", "/healthz", true},
+		{"/healthz", "/healthz/extra", false},
+		{"/vaults/:id", "/vaults/42", true},
+		{"/vaults/:id", "/vaults", false},
+		{"/vaults/:id/secrets", "/vaults/42/keys", false},
+		{"/swagger/*any", "/swagger/index.html", true},
+		{"/swagger/*any", "/swagger/", true},
+	}
+	for _, tt := range tests {
+		if got := routeMatches(tt.pattern, tt.path); got != tt.want {
+			t.Errorf("routeMatches(%q, %q) = %t, want %t", tt.pattern, tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestMailerTest(t *testing.T) {
+	type sentEmail struct{ recipient, sender, subject, body string }
+	newMailer := func(t *testing.T, sendErr error, sent *[]sentEmail) EmailSender {
+		m, err := mailer.NewTemplateMailer(mailer.NewTemplateMailerConfig{
+			From:          "noreply@example.com",
+			SubjectPrefix: "[Vaultify] ",
+			Sender: func(recipient, sender, subject, body, smtpUser, smtpPass string) error {
+				*sent = append(*sent, sentEmail{recipient, sender, subject, body})
+				return sendErr
+			},
+		})
+		if err != nil {
+			t.Fatalf("NewTemplateMailer() error = %v", err)
+		}
+		return m
+	}
+	post := func(s *GinService, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/admin/mailer/test", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		return serve(s, req)
+	}
+
+	t.Run("success", func(t *testing.T) {
+		var sent []sentEmail
+		s := newTestService(t, NewGinServiceConfig{Mailer: newMailer(t, nil, &sent)})
+
+		w := post(s, `{"recipient":"ops@example.com"}`)
+		if w.Code != http.StatusOK {
+			t.Fatalf("got %d %s, want 200", w.Code, w.Body.String())
+		}
+		if len(sent) != 1 {
+			t.Fatalf("sent %d emails, want 1", len(sent))
+		}
+		got := sent[0]
+		if got.recipient != "ops@example.com" || got.sender != "noreply@example.com" {
+			t.Errorf("sent to %q from %q", got.recipient, got.sender)
+		}
+		if got.subject != "[Vaultify] SMTP configuration test" {
+			t.Errorf("subject = %q", got.subject)
+		}
+		if !strings.Contains(got.body, "mailer configuration is working") {
+			t.Errorf("body = %q, want the test_email template", got.body)
+		}
+	})
+
+	t.Run("SMTP failure", func(t *testing.T) {
+		var sent []sentEmail
+		smtpErr := errors.New("failed to send email: 535 5.7.8 authentication failed")
+		s := newTestService(t, NewGinServiceConfig{Mailer: newMailer(t, smtpErr, &sent)})
+
+		w := post(s, `{"recipient":"ops@example.com"}`)
+		var body ErrorResponse
+		_ = json.Unmarshal(w.Body.Bytes(), &body)
+		if w.Code != http.StatusBadGateway || body.Error != smtpErr.Error() {
+			t.Errorf("got %d %q, want 502 carrying the SMTP error", w.Code, body.Error)
+		}
+	})
+
+	t.Run("no mailer", func(t *testing.T) {
+		s := newTestService(t, NewGinServiceConfig{})
+		if w := post(s, `{"recipient":"ops@example.com"}`); w.Code != http.StatusServiceUnavailable {
+			t.Errorf("got %d, want 503", w.Code)
+		}
+	})
+
+	t.Run("invalid recipient", func(t *testing.T) {
+		var sent []sentEmail
+		s := newTestService(t, NewGinServiceConfig{Mailer: newMailer(t, nil, &sent)})
+		if w := post(s, `{"recipient":"not-an-email"}`); w.Code != http.StatusBadRequest {
+			t.Errorf("got %d, want 400", w.Code)
+		}
+		if len(sent) != 0 {
+			t.Errorf("sent %d emails for an invalid recipient", len(sent))
+		}
+	})
+
+	t.Run("outside admin allowlist", func(t *testing.T) {
+		var sent []sentEmail
+		s := newTestService(t, NewGinServiceConfig{
+			AdminAllowedCIDRs: []string{"127.0.0.1/32"},
+			Mailer:            newMailer(t, nil, &sent),
+		})
+		if w := post(s, `{"recipient":"ops@example.com"}`); w.Code != http.StatusForbidden {
+			t.Errorf("got %d, want 403", w.Code)
+		}
+	})
+}
@@ -0,0 +1,376 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// IPAllowlist restricts access to clients whose IP falls within one of the allowed networks.
+// The client IP is c.ClientIP(), so X-Forwarded-For is only honoured when the engine's
+// trusted proxies (router.SetTrustedProxies) include the direct peer.
+type IPAllowlist struct {
+	mu      sync.RWMutex
+	allowed []*net.IPNet
+}
+
+// NewIPAllowlist creates a new IPAllowlist.
+// Entries may be CIDRs ("10.0.0.0/8") or single IPs ("192.168.1.10").
+// An empty allowedCIDRs list denies every request.
+func NewIPAllowlist(allowedCIDRs []string) (*IPAllowlist, error) {
+	allowed, err := parseNetworks(allowedCIDRs)
+	if err != nil {
+		return nil, fmt.Errorf("invalid allowlist entry: %w", err)
+	}
+	return &IPAllowlist{allowed: allowed}, nil
+}
+
+// SetAllowed replaces the allowed networks. The previous list is kept if any entry is invalid.
+func (a *IPAllowlist) SetAllowed(allowedCIDRs []string) error {
+	allowed, err := parseNetworks(allowedCIDRs)
+	if err != nil {
+		return fmt.Errorf("invalid allowlist entry: %w", err)
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.allowed = allowed
+	return nil
+}
+
+// allows reports whether ip belongs to one of the allowed networks.
+func (a *IPAllowlist) allows(ip net.IP) bool {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	return containsIP(a.allowed, ip)
+}
+
+// Middleware returns a Gin middleware that aborts with 403 when the client IP is not allowed.
+// It relies on the engine's trusted proxy configuration: an engine that never called
+// SetTrustedProxies trusts every peer, which lets clients spoof their IP.
+func (a *IPAllowlist) Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ip := net.ParseIP(c.ClientIP())
+		if ip == nil || !a.allows(ip) {
+			log.Printf("IP allowlist denied %s %s from %v (remote %s)", c.Request.Method, c.Request.URL.Path, ip, c.Request.RemoteAddr)
+			c.AbortWithStatusJSON(http.StatusForbidden, ErrorResponse{
+				Error: "forbidden",
+			})
+			return
+		}
+		c.Next()
+	}
+}
+
+// parseNetworks parses CIDRs and bare IPs into networks.
+func parseNetworks(entries []string) ([]*net.IPNet, error) {
+	networks := make([]*net.IPNet, 0, len(entries))
+	for _, entry := range entries {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		if !strings.Contains(entry, "/") {
+			ip := net.ParseIP(entry)
+			if ip == nil {
+				return nil, fmt.Errorf("%q is not a valid IP or CIDR", entry)
+			}
+			bits := 8 * net.IPv6len
+			if ip.To4() != nil {
+				ip = ip.To4()
+				bits = 8 * net.IPv4len
+			}
+			networks = append(networks, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+			continue
+		}
+		_, network, err := net.ParseCIDR(entry)
+		if err != nil {
+			return nil, fmt.Errorf("%q is not a valid IP or CIDR: %w", entry, err)
+		}
+		networks = append(networks, network)
+	}
+	return networks, nil
+}
+
+// containsIP reports whether ip belongs to any of the networks.
+func containsIP(networks []*net.IPNet, ip net.IP) bool {
+	for _, network := range networks {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// DefaultMaintenanceRetryAfter is the Retry-After sent when maintenance mode has no explicit value.
+const DefaultMaintenanceRetryAfter = 2 * time.Minute
+
+// MaintenanceMode blocks mutating requests (POST, PUT, PATCH, DELETE) with 503 while enabled.
+// Reads always pass, as do requests under the exempt path prefixes (e.g. the admin routes
+// that perform the maintenance). It is safe to toggle at runtime.
+type MaintenanceMode struct {
+	enabled        atomic.Bool
+	retryAfter     atomic.Int64 // Seconds.
+	exemptPrefixes []string
+}
+
+// NewMaintenanceMode creates a disabled MaintenanceMode.
+func NewMaintenanceMode(exemptPrefixes ...string) *MaintenanceMode {
+	m := &MaintenanceMode{exemptPrefixes: exemptPrefixes}
+	m.retryAfter.Store(int64(DefaultMaintenanceRetryAfter / time.Second))
+	return m
+}
+
+// Set enables or disables maintenance mode. A non-positive retryAfter uses DefaultMaintenanceRetryAfter.
+func (m *MaintenanceMode) Set(enabled bool, retryAfter time.Duration) {
+	if retryAfter <= 0 {
+		retryAfter = DefaultMaintenanceRetryAfter
+	}
+	m.retryAfter.Store(int64(retryAfter / time.Second))
+	m.enabled.Store(enabled)
+}
+
+// Enabled reports whether maintenance mode is on.
+func (m *MaintenanceMode) Enabled() bool {
+	return m.enabled.Load()
+}
+
+// RetryAfter returns the delay advertised to blocked clients.
+func (m *MaintenanceMode) RetryAfter() time.Duration {
+	return time.Duration(m.retryAfter.Load()) * time.Second
+}
+
+// Middleware returns a Gin middleware that enforces maintenance mode.
+func (m *MaintenanceMode) Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !m.enabled.Load() || !isMutatingMethod(c.Request.Method) {
+			c.Next()
+			return
+		}
+		for _, prefix := range m.exemptPrefixes {
+			if hasPathPrefix(c.Request.URL.Path, prefix) {
+				c.Next()
+				return
+			}
+		}
+		c.Header("Retry-After", strconv.FormatInt(m.retryAfter.Load(), 10))
+		c.AbortWithStatusJSON(http.StatusServiceUnavailable, ErrorResponse{
+			Error: "service is in maintenance mode; writes are temporarily disabled",
+		})
+	}
+}
+
+// hasPathPrefix reports whether path is prefix or lies below it, matching whole
+// segments so that "/admin" covers "/admin/x" but not "/administrators".
+func hasPathPrefix(path, prefix string) bool {
+	prefix = strings.TrimSuffix(prefix, "/")
+	return path == prefix || strings.HasPrefix(path, prefix+"/")
+}
+
+// isMutatingMethod reports whether the HTTP method changes server state.
+func isMutatingMethod(method string) bool {
+	switch method {
+	case http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete:
+		return true
+	}
+	return false
+}
+
+// DefaultMaxBodyBytes is the request body limit applied when none is configured.
+const DefaultMaxBodyBytes int64 = 1 << 20 // 1MB
+
+// MaxBodySize returns a middleware that limits request bodies to limit bytes.
+// overrides maps path prefixes to a different limit (e.g. a larger one for bulk imports);
+// prefixes match on whole path segments and the longest matching prefix wins. Requests whose Content-Length already exceeds the
+// limit are rejected with 413. Chunked bodies are wrapped with http.MaxBytesReader, so
+// reads past the limit fail with *http.MaxBytesError, which handlers map to 413 with
+// respondBindError.
+func MaxBodySize(limit int64, overrides map[string]int64) gin.HandlerFunc {
+	if limit <= 0 {
+		limit = DefaultMaxBodyBytes
+	}
+	return func(c *gin.Context) {
+		max := limit
+		matched := -1
+		for prefix, override := range overrides {
+			if len(prefix) > matched && hasPathPrefix(c.Request.URL.Path, prefix) {
+				max, matched = override, len(prefix)
+			}
+		}
+
+		if c.Request.ContentLength > max {
+			c.AbortWithStatusJSON(http.StatusRequestEntityTooLarge, ErrorResponse{
+				Error: "request body too large",
+			})
+			return
+		}
+		if c.Request.Body != nil {
+			c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, max)
+		}
+		c.Next()
+	}
+}
+
+// maxLoggedBodyBytes caps how much of a request or response body is buffered for logging.
+// Bodies larger than this are not logged at all, since truncated JSON can't be redacted safely.
+const maxLoggedBodyBytes = 64 * 1024
+
+// redactedFieldParts lists substrings that mark a JSON field as sensitive. Field names are
+// normalized by isRedactedField first, so "accessToken", "refresh_token" and "API-Key" all match.
+var redactedFieldParts = []string{
+	"value",
+	"password",
+	"payload",
+	"secret",
+	"token",
+	"key",
+	"authorization",
+}
+
+// isRedactedField reports whether a JSON field name contains any of redactedFieldParts,
+// ignoring case, underscores and hyphens.
+func isRedactedField(name string) bool {
+	name = strings.NewReplacer("_", "", "-", "").Replace(strings.ToLower(name))
+	for _, part := range redactedFieldParts {
+		if strings.Contains(name, part) {
+			return true
+		}
+	}
+	return false
+}
+
+// bodyLogWriter buffers the response body while still writing it to the client.
+type bodyLogWriter struct {
+	gin.ResponseWriter
+	body *bytes.Buffer
+}
+
+func (w *bodyLogWriter) Write(b []byte) (int, error) {
+	if room := w.room(); len(b) > room {
+		w.body.Write(b[:room])
+	} else {
+		w.body.Write(b)
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *bodyLogWriter) WriteString(s string) (int, error) {
+	if room := w.room(); len(s) > room {
+		w.body.WriteString(s[:room])
+	} else {
+		w.body.WriteString(s)
+	}
+	return w.ResponseWriter.WriteString(s)
+}
+
+// room returns how many more bytes may be buffered. One byte past maxLoggedBodyBytes
+// is kept so redactBody can tell the body was too large to log.
+func (w *bodyLogWriter) room() int {
+	if room := maxLoggedBodyBytes + 1 - w.body.Len(); room > 0 {
+		return room
+	}
+	return 0
+}
+
+// bodyLogEntry is the JSON line written for each logged exchange.
+type bodyLogEntry struct {
+	Method       string      `json:"method"`
+	Path         string      `json:"path"`
+	Status       int         `json:"status"`
+	ClientIP     string      `json:"client_ip"`
+	RequestBody  interface{} `json:"request_body,omitempty"`
+	ResponseBody interface{} `json:"response_body,omitempty"`
+}
+
+// BodyLogger returns a middleware that logs request and response bodies as a JSON line
+// for non-2xx responses. Sensitive fields matched by isRedactedField are replaced before logging,
+// and bodies that aren't valid JSON (or exceed maxLoggedBodyBytes) are omitted entirely.
+func BodyLogger() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var reqBody []byte
+		if c.Request.Body != nil {
+			// Read one byte past the cap so oversized bodies can be detected, then
+			// hand the handler a reader that replays what was consumed.
+			buf, err := io.ReadAll(io.LimitReader(c.Request.Body, maxLoggedBodyBytes+1))
+			if err == nil {
+				reqBody = buf
+			}
+			c.Request.Body = readCloser{io.MultiReader(bytes.NewReader(buf), c.Request.Body), c.Request.Body}
+		}
+
+		writer := &bodyLogWriter{ResponseWriter: c.Writer, body: &bytes.Buffer{}}
+		c.Writer = writer
+
+		c.Next()
+
+		status := writer.Status()
+		if status >= 200 && status < 300 {
+			return
+		}
+
+		line, err := json.Marshal(bodyLogEntry{
+			Method:       c.Request.Method,
+			Path:         c.Request.URL.Path,
+			Status:       status,
+			ClientIP:     c.ClientIP(),
+			RequestBody:  redactBody(reqBody),
+			ResponseBody: redactBody(writer.body.Bytes()),
+		})
+		if err != nil {
+			log.Printf("Failed to encode body log entry: %v", err)
+			return
+		}
+		log.Printf("%s", line)
+	}
+}
+
+// readCloser pairs a replacement reader with the original body's Closer.
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
+
+// redactBody parses a JSON body and masks sensitive fields.
+// It returns nil for empty, oversized or non-JSON bodies so nothing unredacted is logged.
+func redactBody(body []byte) interface{} {
+	if len(body) == 0 || len(body) > maxLoggedBodyBytes {
+		return nil
+	}
+	var parsed interface{}
+	if err := json.Unmarshal(body, &parsed); err != nil {
+		return nil
+	}
+	return redactValue(parsed)
+}
+
+// redactValue walks a decoded JSON value and replaces sensitive fields with a placeholder.
+func redactValue(v interface{}) interface{} {
+	switch t := v.(type) {
+	case map[string]interface{}:
+		for k, val := range t {
+			if isRedactedField(k) {
+				t[k] = "[REDACTED]"
+				continue
+			}
+			t[k] = redactValue(val)
+		}
+		return t
+	case []interface{}:
+		for i, val := range t {
+			t[i] = redactValue(val)
+		}
+		return t
+	default:
+		return v
+	}
+}
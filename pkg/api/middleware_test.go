@@ -0,0 +1,271 @@
+package api
+
+import (
+	"bytes"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestIPAllowlist(t *testing.T) {
+	tests := []struct {
+		name       string
+		remoteAddr string
+		xff        string
+		want       int
+	}{
+		{"direct client inside allowlist", "127.0.0.1:1234", "", http.StatusOK},
+		{"direct client outside allowlist", "203.0.113.9:1234", "", http.StatusForbidden},
+		{"spoofed XFF from untrusted peer", "203.0.113.9:1234", "127.0.0.1", http.StatusForbidden},
+		{"trusted proxy forwarding allowed IP", "10.0.0.5:1234", "127.0.0.1", http.StatusOK},
+		{"trusted proxy forwarding denied IP", "10.0.0.5:1234", "203.0.113.9", http.StatusForbidden},
+		{"client-supplied entry left of real hop", "10.0.0.5:1234", "127.0.0.1, 203.0.113.9", http.StatusForbidden},
+		{"malformed hop", "10.0.0.5:1234", "127.0.0.1, not-an-ip", http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newTestService(t, NewGinServiceConfig{
+				AdminAllowedCIDRs: []string{"127.0.0.1/32"},
+				TrustedProxies:    []string{"10.0.0.0/8"},
+			})
+
+			req := httptest.NewRequest(http.MethodGet, "/admin/maintenance", nil)
+			req.RemoteAddr = tt.remoteAddr
+			if tt.xff != "" {
+				req.Header.Set("X-Forwarded-For", tt.xff)
+			}
+			if w := serve(s, req); w.Code != tt.want {
+				t.Errorf("got %d, want %d (body %s)", w.Code, tt.want, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestIPAllowlistReload(t *testing.T) {
+	s := newTestService(t, NewGinServiceConfig{AdminAllowedCIDRs: []string{}})
+	get := func() int {
+		return serve(s, httptest.NewRequest(http.MethodGet, "/admin/maintenance", nil)).Code
+	}
+
+	if code := get(); code != http.StatusForbidden {
+		t.Fatalf("empty allowlist: got %d, want 403", code)
+	}
+	if err := s.SetAdminAllowlist([]string{"192.0.2.0/24"}); err != nil {
+		t.Fatalf("SetAdminAllowlist() error = %v", err)
+	}
+	if code := get(); code != http.StatusOK {
+		t.Errorf("after reload: got %d, want 200", code)
+	}
+	if err := s.SetAdminAllowlist([]string{"not-a-cidr"}); err == nil {
+		t.Error("SetAdminAllowlist() with an invalid entry returned nil error")
+	}
+	if code := get(); code != http.StatusOK {
+		t.Errorf("after invalid reload: got %d, want the previous allowlist kept", code)
+	}
+}
+
+func TestBodyLoggerRedactsSensitiveFields(t *testing.T) {
+	var logs bytes.Buffer
+	log.SetOutput(&logs)
+	defer log.SetOutput(os.Stderr)
+
+	r := gin.New()
+	r.Use(BodyLogger())
+	r.POST("/secrets", func(c *gin.Context) {
+		var body map[string]interface{}
+		_ = c.ShouldBindJSON(&body)
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "invalid secret",
+			"echo":  gin.H{"name": "db", "value": "response-secret"},
+		})
+	})
+
+	reqBody := `{"name":"db","value":"top-level-secret",` +
+		`"metadata":{"owner":"alice","password":"nested-secret"},` +
+		`"items":[{"Value":"array-secret"},{"token":"array-token"}],` +
+		`"accessToken":"access-token-secret","refresh_token":"refresh-token-secret",` +
+		`"apiKey":"api-key-secret","client_secret":"client-secret-value",` +
+		`"privateKey":"private-key-secret","secretValue":"secret-value-secret",` +
+		`"encryptedValue":"encrypted-value-secret"}`
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/secrets", strings.NewReader(reqBody)))
+
+	out := logs.String()
+	for _, leaked := range []string{
+		"top-level-secret", "nested-secret", "array-secret", "array-token", "response-secret",
+		"access-token-secret", "refresh-token-secret", "api-key-secret", "client-secret-value",
+		"private-key-secret", "secret-value-secret", "encrypted-value-secret",
+	} {
+		if strings.Contains(out, leaked) {
+			t.Errorf("log output contains %q: %s", leaked, out)
+		}
+	}
+	for _, kept := range []string{`"[REDACTED]"`, `"owner":"alice"`, `"error":"invalid secret"`, `"status":400`} {
+		if !strings.Contains(out, kept) {
+			t.Errorf("log output missing %s: %s", kept, out)
+		}
+	}
+}
+
+func TestBodyLoggerSkipsSuccessfulResponses(t *testing.T) {
+	var logs bytes.Buffer
+	log.SetOutput(&logs)
+	defer log.SetOutput(os.Stderr)
+
+	r := gin.New()
+	r.Use(BodyLogger())
+	r.GET("/ok", func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"value": "secret"})
+	})
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/ok", nil))
+
+	if logs.Len() != 0 {
+		t.Errorf("2xx response was logged: %s", logs.String())
+	}
+}
+
+func TestBodyLogWriterCapsBuffer(t *testing.T) {
+	large := strings.Repeat("x", 3*maxLoggedBodyBytes)
+	for name, write := range map[string]func(w *bodyLogWriter){
+		"Write":       func(w *bodyLogWriter) { _, _ = w.Write([]byte(large)) },
+		"WriteString": func(w *bodyLogWriter) { _, _ = w.WriteString(large) },
+	} {
+		t.Run(name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(rec)
+			w := &bodyLogWriter{ResponseWriter: c.Writer, body: &bytes.Buffer{}}
+
+			write(w)
+			write(w)
+
+			if got := w.body.Len(); got != maxLoggedBodyBytes+1 {
+				t.Errorf("buffered %d bytes, want %d", got, maxLoggedBodyBytes+1)
+			}
+			if rec.Body.Len() != 2*len(large) {
+				t.Errorf("client received %d bytes, want %d", rec.Body.Len(), 2*len(large))
+			}
+			if redactBody(w.body.Bytes()) != nil {
+				t.Error("oversized body was not omitted from the log")
+			}
+		})
+	}
+}
+
+func TestMaxBodySizeRejectsOversizedBodies(t *testing.T) {
+	body := `{"enabled":true,"retryAfterSeconds":60,"padding":"` + strings.Repeat("x", 1024) + `"}`
+	for _, tt := range []struct {
+		name          string
+		contentLength int64
+	}{
+		{"with Content-Length", int64(len(body))},
+		{"chunked without Content-Length", -1},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newTestService(t, NewGinServiceConfig{MaxBodyBytes: 256})
+
+			req := httptest.NewRequest(http.MethodPut, "/admin/maintenance", strings.NewReader(body))
+			req.Header.Set("Content-Type", "application/json")
+			req.ContentLength = tt.contentLength
+			w := serve(s, req)
+
+			if w.Code != http.StatusRequestEntityTooLarge {
+				t.Errorf("got %d %s, want 413", w.Code, w.Body.String())
+			}
+			if s.maintenance.Enabled() {
+				t.Error("oversized request was applied")
+			}
+		})
+	}
+}
+
+func TestMaxBodySizeOverride(t *testing.T) {
+	body := `{"enabled":false,"padding":"` + strings.Repeat("x", 1024) + `"}`
+	s := newTestService(t, NewGinServiceConfig{
+		MaxBodyBytes:       256,
+		BodyLimitOverrides: map[string]int64{"/admin/maintenance": 4096},
+	})
+
+	req := httptest.NewRequest(http.MethodPut, "/admin/maintenance", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.ContentLength = -1
+	if w := serve(s, req); w.Code != http.StatusOK {
+		t.Errorf("got %d %s, want 200 under the override", w.Code, w.Body.String())
+	}
+}
+
+func TestMaxBodySizeOverrideMatchesWholeSegments(t *testing.T) {
+	r := gin.New()
+	r.Use(MaxBodySize(256, map[string]int64{"/api/v1/import": 4096}))
+	for _, path := range []string{"/api/v1/import", "/api/v1/import/csv", "/api/v1/importer"} {
+		r.POST(path, func(c *gin.Context) { c.Status(http.StatusOK) })
+	}
+	body := strings.Repeat("x", 1024)
+
+	tests := []struct {
+		path string
+		want int
+	}{
+		{"/api/v1/import", http.StatusOK},
+		{"/api/v1/import/csv", http.StatusOK},
+		{"/api/v1/importer", http.StatusRequestEntityTooLarge},
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, tt.path, strings.NewReader(body)))
+		if w.Code != tt.want {
+			t.Errorf("POST %s: got %d, want %d", tt.path, w.Code, tt.want)
+		}
+	}
+}
+
+func TestMaintenanceMode(t *testing.T) {
+	m := NewMaintenanceMode("/admin")
+	r := gin.New()
+	r.Use(m.Middleware())
+	for _, path := range []string{"/api/v1/vaults", "/admin/maintenance", "/administrators"} {
+		r.GET(path, func(c *gin.Context) { c.Status(http.StatusOK) })
+		r.POST(path, func(c *gin.Context) { c.Status(http.StatusOK) })
+		r.DELETE(path, func(c *gin.Context) { c.Status(http.StatusOK) })
+	}
+	do := func(method, path string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(method, path, nil))
+		return w
+	}
+
+	if w := do(http.MethodPost, "/api/v1/vaults"); w.Code != http.StatusOK {
+		t.Fatalf("disabled: POST got %d, want 200", w.Code)
+	}
+
+	m.Set(true, 5*time.Minute)
+	tests := []struct {
+		method, path string
+		want         int
+	}{
+		{http.MethodGet, "/api/v1/vaults", http.StatusOK},
+		{http.MethodPost, "/api/v1/vaults", http.StatusServiceUnavailable},
+		{http.MethodDelete, "/api/v1/vaults", http.StatusServiceUnavailable},
+		{http.MethodPost, "/admin/maintenance", http.StatusOK},
+		{http.MethodPost, "/administrators", http.StatusServiceUnavailable},
+	}
+	for _, tt := range tests {
+		w := do(tt.method, tt.path)
+		if w.Code != tt.want {
+			t.Errorf("enabled: %s %s got %d, want %d", tt.method, tt.path, w.Code, tt.want)
+		}
+		if tt.want == http.StatusServiceUnavailable && w.Header().Get("Retry-After") != "300" {
+			t.Errorf("enabled: %s %s Retry-After = %q, want 300", tt.method, tt.path, w.Header().Get("Retry-After"))
+		}
+	}
+
+	m.Set(false, 0)
+	if w := do(http.MethodPost, "/api/v1/vaults"); w.Code != http.StatusOK {
+		t.Errorf("disabled again: POST got %d, want 200", w.Code)
+	}
+}
@@ -1,10 +1,15 @@
 package cache
 
-import "time"
+import (
+	"context"
+	"time"
+)
 
 // Cache defines the interface for caching services.
 type Cache interface {
 	Get(key string) (string, error)
 	Set(key string, value interface{}, expiration time.Duration) error
 	Delete(key string) error
+	Ping(ctx context.Context) error
+	Close() error
 }
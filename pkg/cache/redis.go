@@ -2,47 +2,115 @@ package cache
 
 import (
 	"context"
-	"time"
+	"crypto/tls"
+	"fmt"
 	"log"
+	"time"
 
 	"github.com/go-redis/redis/v8"
 )
 
 // RedisCache is an implementation of the Cache interface using Redis.
 type RedisCache struct {
-	client *redis.Client
-	ctx    context.Context
+	client     *redis.Client
+	ctx        context.Context
+	keyPrefix  string
+	defaultTTL time.Duration
 }
 
 // NewRedisCacheConfig contains options for creating a new RedisCache.
+// Zero values keep the go-redis defaults.
 type NewRedisCacheConfig struct {
 	Address  string
 	Password string
 	DB       int
+
+	PoolSize     int           // Maximum number of socket connections. Default is 10 per CPU.
+	DialTimeout  time.Duration // Timeout for establishing new connections. Default is 5s.
+	ReadTimeout  time.Duration // Timeout for socket reads. Default is 3s.
+	WriteTimeout time.Duration // Timeout for socket writes. Default is ReadTimeout.
+	MaxRetries   int           // Maximum retries before giving up. Default is 3; -1 disables retries.
+	UseTLS       bool          // Connect over TLS (required by most managed Redis offerings).
+
+	// KeyPrefix is prepended to every key, isolating features that share an instance (e.g. "vaultify:ratelimit:").
+	KeyPrefix string
+	// DefaultTTL is applied when Set is called with a zero expiration. Zero keeps such keys without expiry.
+	DefaultTTL time.Duration
+}
+
+// validate rejects settings go-redis would silently misinterpret.
+func (cfg NewRedisCacheConfig) validate() error {
+	if cfg.Address == "" {
+		return fmt.Errorf("redis address cannot be empty")
+	}
+	if cfg.PoolSize < 0 {
+		return fmt.Errorf("redis pool size must not be negative")
+	}
+	if cfg.DialTimeout < 0 || cfg.ReadTimeout < 0 || cfg.WriteTimeout < 0 {
+		return fmt.Errorf("redis timeouts must not be negative")
+	}
+	if cfg.MaxRetries < -1 {
+		return fmt.Errorf("redis max retries must be -1 (disabled) or greater")
+	}
+	if cfg.DefaultTTL < 0 {
+		return fmt.Errorf("redis default TTL must not be negative")
+	}
+	return nil
+}
+
+// options maps the configuration to go-redis client options.
+func (cfg NewRedisCacheConfig) options() *redis.Options {
+	opts := &redis.Options{
+		Addr:         cfg.Address,
+		Password:     cfg.Password,
+		DB:           cfg.DB,
+		PoolSize:     cfg.PoolSize,
+		DialTimeout:  cfg.DialTimeout,
+		ReadTimeout:  cfg.ReadTimeout,
+		WriteTimeout: cfg.WriteTimeout,
+		MaxRetries:   cfg.MaxRetries,
+	}
+	if cfg.UseTLS {
+		// The server name is taken from Address when the connection is dialed.
+		opts.TLSConfig = &tls.Config{MinVersion: tls.VersionTLS12}
+	}
+	return opts
 }
 
 // NewRedisCache creates a new RedisCache.
 func NewRedisCache(cfg NewRedisCacheConfig) (Cache, error) {
-	rdb := redis.NewClient(&redis.Options{
-		Addr:     cfg.Address,
-		Password: cfg.Password,
-		DB:       cfg.DB,
-	})
+	if err := cfg.validate(); err != nil {
+		log.Printf("Invalid Redis configuration: %v", err)
+		return nil, err
+	}
+
+	rdb := redis.NewClient(cfg.options())
 
 	ctx := context.Background()
 	_, err := rdb.Ping(ctx).Result()
 	if err != nil {
 		log.Printf("Failed to connect to Redis: %v", err)
+		rdb.Close()
 		return nil, err
 	}
 
 	log.Println("Successfully connected to Redis")
-	return &RedisCache{client: rdb, ctx: ctx}, nil
+	return &RedisCache{
+		client:     rdb,
+		ctx:        ctx,
+		keyPrefix:  cfg.KeyPrefix,
+		defaultTTL: cfg.DefaultTTL,
+	}, nil
+}
+
+// key returns the namespaced Redis key for the given cache key.
+func (r *RedisCache) key(key string) string {
+	return r.keyPrefix + key
 }
 
 // Get retrieves a value from Redis.
 func (r *RedisCache) Get(key string) (string, error) {
-	val, err := r.client.Get(r.ctx, key).Result()
+	val, err := r.client.Get(r.ctx, r.key(key)).Result()
 	if err == redis.Nil {
 		return "", nil // Key does not exist
 	} else if err != nil {
@@ -53,8 +121,12 @@ func (r *RedisCache) Get(key string) (string, error) {
 }
 
 // Set stores a value in Redis.
+// A zero expiration uses the configured default TTL.
 func (r *RedisCache) Set(key string, value interface{}, expiration time.Duration) error {
-	err := r.client.Set(r.ctx, key, value, expiration).Err()
+	if expiration == 0 {
+		expiration = r.defaultTTL
+	}
+	err := r.client.Set(r.ctx, r.key(key), value, expiration).Err()
 	if err != nil {
 		log.Printf("Error setting key %s in Redis: %v", key, err)
 		return err
@@ -64,10 +136,20 @@ func (r *RedisCache) Set(key string, value interface{}, expiration time.Duration
 
 // Delete removes a value from Redis.
 func (r *RedisCache) Delete(key string) error {
-	err := r.client.Del(r.ctx, key).Err()
+	err := r.client.Del(r.ctx, r.key(key)).Err()
 	if err != nil {
 		log.Printf("Error deleting key %s from Redis: %v", key, err)
 		return err
 	}
 	return nil
 }
+
+// Ping checks that Redis is reachable, giving up when ctx is done.
+func (r *RedisCache) Ping(ctx context.Context) error {
+	return r.client.Ping(ctx).Err()
+}
+
+// Close closes the Redis client and its connection pool.
+func (r *RedisCache) Close() error {
+	return r.client.Close()
+}
@@ -0,0 +1,150 @@
+package cache
+
+import (
+	"crypto/tls"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+)
+
+func TestNewRedisCacheConfigValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     NewRedisCacheConfig
+		wantErr bool
+	}{
+		{"defaults", NewRedisCacheConfig{Address: "localhost:6379"}, false},
+		{"tuned", NewRedisCacheConfig{Address: "localhost:6379", PoolSize: 20, DialTimeout: time.Second, MaxRetries: 5}, false},
+		{"retries disabled", NewRedisCacheConfig{Address: "localhost:6379", MaxRetries: -1}, false},
+		{"missing address", NewRedisCacheConfig{}, true},
+		{"negative pool size", NewRedisCacheConfig{Address: "localhost:6379", PoolSize: -1}, true},
+		{"negative timeout", NewRedisCacheConfig{Address: "localhost:6379", ReadTimeout: -time.Second}, true},
+		{"invalid retries", NewRedisCacheConfig{Address: "localhost:6379", MaxRetries: -2}, true},
+		{"negative default TTL", NewRedisCacheConfig{Address: "localhost:6379", DefaultTTL: -time.Second}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.cfg.validate(); (err != nil) != tt.wantErr {
+				t.Errorf("validate() error = %v, wantErr %t", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestNewRedisCacheConfigOptions(t *testing.T) {
+	cfg := NewRedisCacheConfig{
+		Address:      "redis.example.com:6380",
+		PoolSize:     20,
+		DialTimeout:  2 * time.Second,
+		ReadTimeout:  time.Second,
+		WriteTimeout: 3 * time.Second,
+		MaxRetries:   5,
+		UseTLS:       true,
+	}
+	opts := cfg.options()
+
+	if opts.Addr != cfg.Address || opts.PoolSize != 20 || opts.MaxRetries != 5 {
+		t.Errorf("options = %+v, want address, pool size and retries from the config", opts)
+	}
+	if opts.DialTimeout != 2*time.Second || opts.ReadTimeout != time.Second || opts.WriteTimeout != 3*time.Second {
+		t.Errorf("timeouts = %v/%v/%v, want 2s/1s/3s", opts.DialTimeout, opts.ReadTimeout, opts.WriteTimeout)
+	}
+	if opts.TLSConfig == nil || opts.TLSConfig.MinVersion != tls.VersionTLS12 {
+		t.Errorf("TLSConfig = %+v, want TLS 1.2 minimum", opts.TLSConfig)
+	}
+
+	cfg.UseTLS = false
+	if cfg.options().TLSConfig != nil {
+		t.Error("TLSConfig set without UseTLS")
+	}
+}
+
+func TestNewRedisCache(t *testing.T) {
+	mr := miniredis.RunT(t)
+
+	c, err := NewRedisCache(NewRedisCacheConfig{
+		Address:     mr.Addr(),
+		PoolSize:    2,
+		DialTimeout: time.Second,
+	})
+	if err != nil {
+		t.Fatalf("NewRedisCache() error = %v", err)
+	}
+	defer c.Close()
+
+	// A plain-text server can't complete the TLS handshake, which proves TLS is negotiated.
+	_, err = NewRedisCache(NewRedisCacheConfig{
+		Address:     mr.Addr(),
+		DialTimeout: time.Second,
+		ReadTimeout: time.Second,
+		MaxRetries:  -1,
+		UseTLS:      true,
+	})
+	if err == nil {
+		t.Error("NewRedisCache() with UseTLS against a plain-text server returned nil error")
+	}
+
+	if _, err := NewRedisCache(NewRedisCacheConfig{Address: mr.Addr(), PoolSize: -1}); err == nil {
+		t.Error("NewRedisCache() with an invalid config returned nil error")
+	}
+}
+
+func TestRedisCacheKeyPrefixAndDefaultTTL(t *testing.T) {
+	mr := miniredis.RunT(t)
+	c, err := NewRedisCache(NewRedisCacheConfig{
+		Address:    mr.Addr(),
+		KeyPrefix:  "vaultify:ratelimit:",
+		DefaultTTL: time.Hour,
+	})
+	if err != nil {
+		t.Fatalf("NewRedisCache() error = %v", err)
+	}
+	defer c.Close()
+
+	if err := c.Set("user1", "3", 0); err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+	if !mr.Exists("vaultify:ratelimit:user1") || mr.Exists("user1") {
+		t.Errorf("keys = %v, want only the prefixed key", mr.Keys())
+	}
+	if ttl := mr.TTL("vaultify:ratelimit:user1"); ttl != time.Hour {
+		t.Errorf("TTL with zero expiration = %v, want the 1h default", ttl)
+	}
+
+	if err := c.Set("user2", "1", time.Minute); err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+	if ttl := mr.TTL("vaultify:ratelimit:user2"); ttl != time.Minute {
+		t.Errorf("TTL with explicit expiration = %v, want 1m", ttl)
+	}
+
+	if got, err := c.Get("user1"); err != nil || got != "3" {
+		t.Errorf("Get() = %q, %v, want the prefixed value", got, err)
+	}
+	if err := c.Delete("user1"); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+	if mr.Exists("vaultify:ratelimit:user1") {
+		t.Error("Delete() did not remove the prefixed key")
+	}
+}
+
+func TestRedisCacheWithoutDefaultTTL(t *testing.T) {
+	mr := miniredis.RunT(t)
+	c, err := NewRedisCache(NewRedisCacheConfig{Address: mr.Addr()})
+	if err != nil {
+		t.Fatalf("NewRedisCache() error = %v", err)
+	}
+	defer c.Close()
+
+	if err := c.Set("k", "v", 0); err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+	if !mr.Exists("k") {
+		t.Errorf("keys = %v, want the unprefixed key", mr.Keys())
+	}
+	if ttl := mr.TTL("k"); ttl != 0 {
+		t.Errorf("TTL = %v, want no expiry", ttl)
+	}
+}
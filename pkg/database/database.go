@@ -6,7 +6,11 @@ import "context"
 type FirestoreDB interface {
 	Get(ctx context.Context, collection string, docID string) (map[string]interface{}, error)
 	Add(ctx context.Context, collection string, data interface{}) (string, error)
+	// Update writes only the given fields (dotted paths allowed) plus updatedAt.
+	// The document must exist: unlike an upsert, a missing document returns a NotFound error.
 	Update(ctx context.Context, collection string, docID string, data map[string]interface{}) error
 	Delete(ctx context.Context, collection string, docID string) error
 	Query(ctx context.Context, collection string, query map[string]interface{}) ([]map[string]interface{}, error)
+	Ping(ctx context.Context) error
+	Close() error
 }
@@ -2,72 +2,143 @@ package database
 
 import (
 	"context"
+	"fmt"
 	"log"
 
 	"cloud.google.com/go/firestore"
+	"google.golang.org/api/iterator"
 	"google.golang.org/api/option"
 )
 
+// updatedAtField is set to the server timestamp by Update.
+const updatedAtField = "updatedAt"
+
+// healthCollection is read by Ping to verify connectivity. It does not need to exist.
+const healthCollection = "_health"
+
 // FirestoreService implements the FirestoreDB interface.
 type FirestoreService struct {
-	client *firestore.Client
-	projectID string
+	writer           documentStore // Used for mutations.
+	reader           documentStore // Used for reads. Same as writer unless ReadEndpoint is set.
+	projectID        string
+	collectionPrefix string
+}
+
+// documentStore is the subset of Firestore operations used by FirestoreService.
+// clientStore implements it on top of a *firestore.Client; tests substitute fakes.
+// Collection names passed to it already carry the configured prefix.
+type documentStore interface {
+	get(ctx context.Context, collection, docID string) (map[string]interface{}, error)
+	add(ctx context.Context, collection string, data interface{}) (string, error)
+	update(ctx context.Context, collection, docID string, updates []firestore.Update) error
+	delete(ctx context.Context, collection, docID string) error
+	// probe reads at most one document from collection. An empty collection is not an error.
+	probe(ctx context.Context, collection string) error
+	close() error
 }
 
 // NewFirestoreServiceConfig contains options for creating a new FirestoreService.
 type NewFirestoreServiceConfig struct {
 	ProjectID       string
 	CredentialsFile string // Path to the service account key JSON file. If empty, ADC will be used.
+	// CollectionPrefix is prepended to every collection name (e.g. "staging_").
+	// Leave empty to use the collection names as given.
+	CollectionPrefix string
+	// ReadEndpoint, if set, creates a second client pointed at this endpoint (host:port)
+	// and routes Get/Query through it. Mutations always use the default client; Ping checks both.
+	ReadEndpoint string
 }
 
 // NewFirestoreService creates a new instance of FirestoreService.
 func NewFirestoreService(ctx context.Context, cfg NewFirestoreServiceConfig) (FirestoreDB, error) {
-	var client *firestore.Client
-	var err error
-
+	var opts []option.ClientOption
 	if cfg.CredentialsFile != "" {
-		client, err = firestore.NewClient(ctx, cfg.ProjectID, option.WithCredentialsFile(cfg.CredentialsFile))
-	} else {
-		// Use Application Default Credentials
-		client, err = firestore.NewClient(ctx, cfg.ProjectID)
+		opts = append(opts, option.WithCredentialsFile(cfg.CredentialsFile))
 	}
+	// Without a credentials file, Application Default Credentials are used.
 
+	client, err := firestore.NewClient(ctx, cfg.ProjectID, opts...)
 	if err != nil {
 		log.Printf("Failed to create Firestore client: %v", err)
 		return nil, err
 	}
 
+	readClient := client
+	if cfg.ReadEndpoint != "" {
+		readOpts := append(opts, option.WithEndpoint(cfg.ReadEndpoint))
+		readClient, err = firestore.NewClient(ctx, cfg.ProjectID, readOpts...)
+		if err != nil {
+			log.Printf("Failed to create Firestore read client for %s: %v", cfg.ReadEndpoint, err)
+			client.Close()
+			return nil, err
+		}
+		log.Printf("Firestore reads routed to %s", cfg.ReadEndpoint)
+	}
+
 	log.Println("Successfully connected to Firestore")
-	return &FirestoreService{client: client, projectID: cfg.ProjectID}, nil
+	writer := &clientStore{client: client}
+	var reader documentStore = writer
+	if readClient != client {
+		reader = &clientStore{client: readClient}
+	}
+	return newFirestoreService(writer, reader, cfg), nil
+}
+
+// newFirestoreService creates a FirestoreService on top of the given stores.
+// A nil reader routes reads through writer.
+func newFirestoreService(writer, reader documentStore, cfg NewFirestoreServiceConfig) *FirestoreService {
+	if reader == nil {
+		reader = writer
+	}
+	return &FirestoreService{
+		writer:           writer,
+		reader:           reader,
+		projectID:        cfg.ProjectID,
+		collectionPrefix: cfg.CollectionPrefix,
+	}
+}
+
+// collection returns the named collection with the configured prefix applied.
+func (s *FirestoreService) collection(name string) string {
+	return s.collectionPrefix + name
 }
 
 // Get retrieves a document from a Firestore collection.
 func (s *FirestoreService) Get(ctx context.Context, collection string, docID string) (map[string]interface{}, error) {
-	doc, err := s.client.Collection(collection).Doc(docID).Get(ctx)
+	data, err := s.reader.get(ctx, s.collection(collection), docID)
 	if err != nil {
 		log.Printf("Error getting document %s from collection %s: %v", docID, collection, err)
 		return nil, err
 	}
-	return doc.Data(), nil
+	return data, nil
 }
 
 // Add adds a new document to a Firestore collection.
 // Returns the ID of the newly created document.
 func (s *FirestoreService) Add(ctx context.Context, collection string, data interface{}) (string, error) {
-	docRef, _, err := s.client.Collection(collection).Add(ctx, data)
+	docID, err := s.writer.add(ctx, s.collection(collection), data)
 	if err != nil {
 		log.Printf("Error adding document to collection %s: %v", collection, err)
 		return "", err
 	}
-	return docRef.ID, nil
+	return docID, nil
 }
 
-// Update updates an existing document in a Firestore collection.
+// Update applies a targeted update to an existing document in a Firestore collection.
+// Only the given fields are written, plus updatedAt set to the server timestamp, so concurrent
+// changes to other fields are not overwritten. Keys may be dotted field paths
+// (e.g. "sharedWith.user123") to change a single map entry without replacing the whole map.
+// Unlike Set, the document must already exist.
 func (s *FirestoreService) Update(ctx context.Context, collection string, docID string, data map[string]interface{}) error {
-	// Firestore's Update method requires a []firestore.Update.
-	// For simplicity, this example uses Set with MergeAll, which overwrites fields.
-	// For more granular updates, you'd construct a []firestore.Update.
-	_, err := s.client.Collection(collection).Doc(docID).Set(ctx, data, firestore.MergeAll)
+	updates := make([]firestore.Update, 0, len(data)+1)
+	for path, value := range data {
+		updates = append(updates, firestore.Update{Path: path, Value: value})
+	}
+	if _, ok := data[updatedAtField]; !ok {
+		updates = append(updates, firestore.Update{Path: updatedAtField, Value: firestore.ServerTimestamp})
+	}
+
+	err := s.writer.update(ctx, s.collection(collection), docID, updates)
 	if err != nil {
 		log.Printf("Error updating document %s in collection %s: %v", docID, collection, err)
 		return err
@@ -77,7 +148,7 @@ func (s *FirestoreService) Update(ctx context.Context, collection string, docID
 
 // Delete removes a document from a Firestore collection.
 func (s *FirestoreService) Delete(ctx context.Context, collection string, docID string) error {
-	_, err := s.client.Collection(collection).Doc(docID).Delete(ctx)
+	err := s.writer.delete(ctx, s.collection(collection), docID)
 	if err != nil {
 		log.Printf("Error deleting document %s from collection %s: %v", docID, collection, err)
 		return err
@@ -90,17 +161,112 @@ func (s *FirestoreService) Delete(ctx context.Context, collection string, docID
 func (s *FirestoreService) Query(ctx context.Context, collection string, queryParams map[string]interface{}) ([]map[string]interface{}, error) {
 	// This is a placeholder for query logic.
 	// Firestore queries are typically constructed like:
-	// q := s.client.Collection(collection).Where("field", "==", value)
+	// q := client.Collection(s.collection(collection)).Where("field", "==", value)
 	// iter := q.Documents(ctx)
 	// For now, this will return an empty slice.
-	log.Printf("Query method called for collection %s with params %v. This is a placeholder.", collection, queryParams)
+	// Once implemented, it should run through s.reader like Get.
+	log.Printf("Query method called for collection %s with params %v. This is a placeholder.", s.collection(collection), queryParams)
 	return make([]map[string]interface{}, 0), nil
 }
 
-// Close closes the Firestore client.
+// Ping checks that Firestore is reachable by reading at most one document
+// from the health collection. An empty or missing collection is not an error.
+// When reads use a separate client, both clients are probed and the first error is returned.
+func (s *FirestoreService) Ping(ctx context.Context) error {
+	if err := s.writer.probe(ctx, s.collection(healthCollection)); err != nil {
+		return err
+	}
+	if s.reader != s.writer {
+		return s.reader.probe(ctx, s.collection(healthCollection))
+	}
+	return nil
+}
+
+// Close closes the Firestore clients.
 func (s *FirestoreService) Close() error {
-	if s.client != nil {
-		return s.client.Close()
+	var err error
+	if s.reader != nil && s.reader != s.writer {
+		err = s.reader.close()
+	}
+	if s.writer != nil {
+		if closeErr := s.writer.close(); closeErr != nil {
+			err = closeErr
+		}
+	}
+	return err
+}
+
+// clientStore implements documentStore with a Firestore client.
+type clientStore struct {
+	client *firestore.Client
+}
+
+// collectionRef returns a reference to the named collection. client.Collection returns nil
+// for names that are not valid collection IDs (e.g. "staging/vaults"), so that case is
+// reported as an error instead of panicking on the first use.
+func (c *clientStore) collectionRef(collection string) (*firestore.CollectionRef, error) {
+	ref := c.client.Collection(collection)
+	if ref == nil {
+		return nil, fmt.Errorf("invalid Firestore collection name %q", collection)
+	}
+	return ref, nil
+}
+
+func (c *clientStore) get(ctx context.Context, collection, docID string) (map[string]interface{}, error) {
+	ref, err := c.collectionRef(collection)
+	if err != nil {
+		return nil, err
+	}
+	doc, err := ref.Doc(docID).Get(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return doc.Data(), nil
+}
+
+func (c *clientStore) add(ctx context.Context, collection string, data interface{}) (string, error) {
+	ref, err := c.collectionRef(collection)
+	if err != nil {
+		return "", err
+	}
+	docRef, _, err := ref.Add(ctx, data)
+	if err != nil {
+		return "", err
+	}
+	return docRef.ID, nil
+}
+
+func (c *clientStore) update(ctx context.Context, collection, docID string, updates []firestore.Update) error {
+	ref, err := c.collectionRef(collection)
+	if err != nil {
+		return err
+	}
+	_, err = ref.Doc(docID).Update(ctx, updates)
+	return err
+}
+
+func (c *clientStore) delete(ctx context.Context, collection, docID string) error {
+	ref, err := c.collectionRef(collection)
+	if err != nil {
+		return err
+	}
+	_, err = ref.Doc(docID).Delete(ctx)
+	return err
+}
+
+func (c *clientStore) probe(ctx context.Context, collection string) error {
+	ref, err := c.collectionRef(collection)
+	if err != nil {
+		return err
+	}
+	iter := ref.Limit(1).Documents(ctx)
+	defer iter.Stop()
+	if _, err := iter.Next(); err != nil && err != iterator.Done {
+		return err
 	}
 	return nil
 }
+
+func (c *clientStore) close() error {
+	return c.client.Close()
+}
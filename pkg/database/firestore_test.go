@@ -0,0 +1,306 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+
+	"cloud.google.com/go/firestore"
+)
+
+// errNotFound mimics the NotFound error Firestore returns for a missing document.
+var errNotFound = errors.New("not found")
+
+// fakeStore is an in-memory documentStore that records which operations it served.
+type fakeStore struct {
+	mu       sync.Mutex
+	docs     map[string]map[string]interface{} // Keyed by "collection/docID".
+	calls    []string                          // "op collection" for every call.
+	probeErr error                             // Returned by probe, if set.
+	closed   bool
+}
+
+func newFakeStore() *fakeStore {
+	return &fakeStore{docs: make(map[string]map[string]interface{})}
+}
+
+func (f *fakeStore) record(op, collection string) {
+	f.calls = append(f.calls, op+" "+collection)
+}
+
+func (f *fakeStore) get(ctx context.Context, collection, docID string) (map[string]interface{}, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.record("get", collection)
+	doc, ok := f.docs[collection+"/"+docID]
+	if !ok {
+		return nil, errNotFound
+	}
+	return doc, nil
+}
+
+func (f *fakeStore) add(ctx context.Context, collection string, data interface{}) (string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.record("add", collection)
+	docID := fmt.Sprintf("doc%d", len(f.docs)+1)
+	doc, _ := data.(map[string]interface{})
+	f.docs[collection+"/"+docID] = doc
+	return docID, nil
+}
+
+// update applies each field path like Firestore does: dotted paths change nested map entries
+// and fields that are not listed are left untouched.
+func (f *fakeStore) update(ctx context.Context, collection, docID string, updates []firestore.Update) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.record("update", collection)
+	doc, ok := f.docs[collection+"/"+docID]
+	if !ok {
+		return errNotFound
+	}
+	for _, u := range updates {
+		parts := strings.Split(u.Path, ".")
+		target := doc
+		for _, part := range parts[:len(parts)-1] {
+			next, ok := target[part].(map[string]interface{})
+			if !ok {
+				next = make(map[string]interface{})
+				target[part] = next
+			}
+			target = next
+		}
+		target[parts[len(parts)-1]] = u.Value
+	}
+	return nil
+}
+
+func (f *fakeStore) delete(ctx context.Context, collection, docID string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.record("delete", collection)
+	delete(f.docs, collection+"/"+docID)
+	return nil
+}
+
+func (f *fakeStore) probe(ctx context.Context, collection string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.record("probe", collection)
+	return f.probeErr
+}
+
+func (f *fakeStore) close() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.closed = true
+	return nil
+}
+
+func TestFirestoreServiceRoutesReadsToReadClient(t *testing.T) {
+	ctx := context.Background()
+	writer, reader := newFakeStore(), newFakeStore()
+	reader.docs["vaults/v1"] = map[string]interface{}{"name": "from reader"}
+	s := newFirestoreService(writer, reader, NewFirestoreServiceConfig{})
+
+	doc, err := s.Get(ctx, "vaults", "v1")
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	if doc["name"] != "from reader" {
+		t.Errorf("Get() = %v, want the document from the read client", doc)
+	}
+	if err := s.Ping(ctx); err != nil {
+		t.Fatalf("Ping() error = %v", err)
+	}
+
+	if _, err := s.Add(ctx, "vaults", map[string]interface{}{"name": "new"}); err != nil {
+		t.Fatalf("Add() error = %v", err)
+	}
+	writer.docs["vaults/v2"] = map[string]interface{}{}
+	if err := s.Update(ctx, "vaults", "v2", map[string]interface{}{"name": "renamed"}); err != nil {
+		t.Fatalf("Update() error = %v", err)
+	}
+	if err := s.Delete(ctx, "vaults", "v2"); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+
+	wantReader := []string{"get vaults", "probe _health"}
+	wantWriter := []string{"probe _health", "add vaults", "update vaults", "delete vaults"}
+	if strings.Join(reader.calls, ",") != strings.Join(wantReader, ",") {
+		t.Errorf("read client calls = %v, want %v", reader.calls, wantReader)
+	}
+	if strings.Join(writer.calls, ",") != strings.Join(wantWriter, ",") {
+		t.Errorf("write client calls = %v, want %v", writer.calls, wantWriter)
+	}
+
+	if err := s.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+	if !reader.closed || !writer.closed {
+		t.Errorf("Close() closed reader=%t writer=%t, want both", reader.closed, writer.closed)
+	}
+}
+
+func TestFirestoreServicePingProbesBothClients(t *testing.T) {
+	ctx := context.Background()
+	errUnavailable := errors.New("unavailable")
+	tests := []struct {
+		name                 string
+		writerErr, readerErr error
+		want                 error
+	}{
+		{"both healthy", nil, nil, nil},
+		{"read client down", nil, errUnavailable, errUnavailable},
+		{"write client down", errUnavailable, nil, errUnavailable},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			writer, reader := newFakeStore(), newFakeStore()
+			writer.probeErr, reader.probeErr = tt.writerErr, tt.readerErr
+			s := newFirestoreService(writer, reader, NewFirestoreServiceConfig{})
+
+			if err := s.Ping(ctx); !errors.Is(err, tt.want) {
+				t.Errorf("Ping() error = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestClientStoreRejectsInvalidCollection(t *testing.T) {
+	// With the emulator host set, NewClient needs no credentials and does not dial.
+	t.Setenv("FIRESTORE_EMULATOR_HOST", "localhost:8080")
+	ctx := context.Background()
+	client, err := firestore.NewClient(ctx, "test-project")
+	if err != nil {
+		t.Fatalf("NewClient() error = %v", err)
+	}
+	defer client.Close()
+	store := &clientStore{client: client}
+
+	// A prefix such as "staging/" turns "vaults" into a path that client.Collection rejects with nil.
+	const collection = "staging/vaults"
+	ops := map[string]func() error{
+		"get": func() error {
+			_, err := store.get(ctx, collection, "v1")
+			return err
+		},
+		"add": func() error {
+			_, err := store.add(ctx, collection, map[string]interface{}{})
+			return err
+		},
+		"update": func() error {
+			return store.update(ctx, collection, "v1", []firestore.Update{{Path: "name", Value: "x"}})
+		},
+		"delete": func() error { return store.delete(ctx, collection, "v1") },
+		"probe":  func() error { return store.probe(ctx, collection) },
+	}
+	for name, op := range ops {
+		if err := op(); err == nil || !strings.Contains(err.Error(), "invalid Firestore collection name") {
+			t.Errorf("%s() error = %v, want an invalid collection name error", name, err)
+		}
+	}
+}
+
+func TestFirestoreServiceWithoutReadClientUsesWriter(t *testing.T) {
+	ctx := context.Background()
+	writer := newFakeStore()
+	writer.docs["vaults/v1"] = map[string]interface{}{"name": "vault"}
+	s := newFirestoreService(writer, nil, NewFirestoreServiceConfig{})
+
+	if _, err := s.Get(ctx, "vaults", "v1"); err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	if len(writer.calls) != 1 || writer.calls[0] != "get vaults" {
+		t.Errorf("calls = %v, want the read served by the write client", writer.calls)
+	}
+}
+
+func TestFirestoreServiceAppliesCollectionPrefix(t *testing.T) {
+	ctx := context.Background()
+	writer, reader := newFakeStore(), newFakeStore()
+	reader.docs["staging_vaults/v1"] = map[string]interface{}{}
+	writer.docs["staging_vaults/v1"] = map[string]interface{}{}
+	s := newFirestoreService(writer, reader, NewFirestoreServiceConfig{CollectionPrefix: "staging_"})
+
+	if _, err := s.Get(ctx, "vaults", "v1"); err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	if err := s.Ping(ctx); err != nil {
+		t.Fatalf("Ping() error = %v", err)
+	}
+	if _, err := s.Add(ctx, "vaults", map[string]interface{}{}); err != nil {
+		t.Fatalf("Add() error = %v", err)
+	}
+	if err := s.Update(ctx, "vaults", "v1", map[string]interface{}{"name": "x"}); err != nil {
+		t.Fatalf("Update() error = %v", err)
+	}
+	if err := s.Delete(ctx, "vaults", "v1"); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+
+	for _, call := range append(reader.calls, writer.calls...) {
+		if !strings.HasSuffix(call, " staging_vaults") && !strings.HasSuffix(call, " staging__health") {
+			t.Errorf("call %q did not use the prefixed collection", call)
+		}
+	}
+}
+
+func TestFirestoreServiceUpdateKeepsConcurrentChanges(t *testing.T) {
+	ctx := context.Background()
+	store := newFakeStore()
+	store.docs["vaults/v1"] = map[string]interface{}{
+		"name":       "Old name",
+		"ownerId":    "owner",
+		"sharedWith": map[string]interface{}{"user1": "read"},
+	}
+	s := newFirestoreService(store, nil, NewFirestoreServiceConfig{})
+
+	// A name edit and a share addition race on the same vault.
+	var wg sync.WaitGroup
+	errs := make(chan error, 2)
+	for _, data := range []map[string]interface{}{
+		{"name": "New name"},
+		{"sharedWith.user2": "write"},
+	} {
+		wg.Add(1)
+		go func(data map[string]interface{}) {
+			defer wg.Done()
+			errs <- s.Update(ctx, "vaults", "v1", data)
+		}(data)
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Fatalf("Update() error = %v", err)
+		}
+	}
+
+	doc := store.docs["vaults/v1"]
+	if doc["name"] != "New name" {
+		t.Errorf("name = %v, want the concurrent name edit to persist", doc["name"])
+	}
+	shared := doc["sharedWith"].(map[string]interface{})
+	if shared["user1"] != "read" || shared["user2"] != "write" {
+		t.Errorf("sharedWith = %v, want the existing and the concurrently added share", shared)
+	}
+	if doc["ownerId"] != "owner" {
+		t.Errorf("ownerId = %v, want untouched fields to be kept", doc["ownerId"])
+	}
+	if doc[updatedAtField] != firestore.ServerTimestamp {
+		t.Errorf("%s = %v, want the server timestamp", updatedAtField, doc[updatedAtField])
+	}
+}
+
+func TestFirestoreServiceUpdateMissingDocument(t *testing.T) {
+	s := newFirestoreService(newFakeStore(), nil, NewFirestoreServiceConfig{})
+
+	err := s.Update(context.Background(), "vaults", "missing", map[string]interface{}{"name": "x"})
+	if !errors.Is(err, errNotFound) {
+		t.Errorf("Update() error = %v, want NotFound instead of creating the document", err)
+	}
+}
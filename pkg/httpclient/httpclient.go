@@ -0,0 +1,91 @@
+package httpclient
+
+import (
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// Defaults applied when the corresponding NewHTTPClientConfig field is zero.
+const (
+	DefaultTimeout               = 30 * time.Second
+	DefaultDialTimeout           = 5 * time.Second
+	DefaultTLSHandshakeTimeout   = 5 * time.Second
+	DefaultResponseHeaderTimeout = 10 * time.Second
+	DefaultIdleConnTimeout       = 90 * time.Second
+	DefaultMaxIdleConns          = 100
+	DefaultMaxIdleConnsPerHost   = 10
+)
+
+// NewHTTPClientConfig contains options for creating the shared outbound HTTP client.
+// Zero values use the package defaults.
+type NewHTTPClientConfig struct {
+	Timeout               time.Duration // Whole request, including reading the response body.
+	DialTimeout           time.Duration // Establishing the TCP connection.
+	TLSHandshakeTimeout   time.Duration
+	ResponseHeaderTimeout time.Duration // Waiting for response headers once the request is written.
+	IdleConnTimeout       time.Duration // How long idle keep-alive connections stay in the pool.
+	MaxIdleConns          int
+	MaxIdleConnsPerHost   int
+	// ProxyURL routes every request through the given proxy. Empty uses the
+	// HTTP_PROXY/HTTPS_PROXY/NO_PROXY environment variables.
+	ProxyURL string
+}
+
+// NewHTTPClient creates an *http.Client for calls to external services (billing, webhooks...).
+// Unlike http.DefaultClient it always has timeouts, so a hung remote endpoint cannot
+// hold goroutines and connections forever. Share one client per process so the
+// connection pool is reused.
+func NewHTTPClient(cfg NewHTTPClientConfig) (*http.Client, error) {
+	if cfg.Timeout < 0 || cfg.DialTimeout < 0 || cfg.TLSHandshakeTimeout < 0 ||
+		cfg.ResponseHeaderTimeout < 0 || cfg.IdleConnTimeout < 0 {
+		return nil, fmt.Errorf("http client timeouts must not be negative")
+	}
+	if cfg.MaxIdleConns < 0 || cfg.MaxIdleConnsPerHost < 0 {
+		return nil, fmt.Errorf("http client connection limits must not be negative")
+	}
+
+	proxy := http.ProxyFromEnvironment
+	if cfg.ProxyURL != "" {
+		proxyURL, err := url.Parse(cfg.ProxyURL)
+		if err == nil && (proxyURL.Scheme == "" || proxyURL.Host == "") {
+			err = fmt.Errorf("proxy URL %q must be absolute", cfg.ProxyURL)
+		}
+		if err != nil {
+			log.Printf("Invalid outbound HTTP proxy URL: %v", err)
+			return nil, err
+		}
+		proxy = http.ProxyURL(proxyURL)
+	}
+
+	transport := &http.Transport{
+		Proxy: proxy,
+		DialContext: (&net.Dialer{
+			Timeout:   orDefault(cfg.DialTimeout, DefaultDialTimeout),
+			KeepAlive: 30 * time.Second,
+		}).DialContext,
+		ForceAttemptHTTP2:     true,
+		TLSHandshakeTimeout:   orDefault(cfg.TLSHandshakeTimeout, DefaultTLSHandshakeTimeout),
+		ResponseHeaderTimeout: orDefault(cfg.ResponseHeaderTimeout, DefaultResponseHeaderTimeout),
+		IdleConnTimeout:       orDefault(cfg.IdleConnTimeout, DefaultIdleConnTimeout),
+		MaxIdleConns:          orDefault(cfg.MaxIdleConns, DefaultMaxIdleConns),
+		MaxIdleConnsPerHost:   orDefault(cfg.MaxIdleConnsPerHost, DefaultMaxIdleConnsPerHost),
+		ExpectContinueTimeout: 1 * time.Second,
+	}
+
+	return &http.Client{
+		Transport: transport,
+		Timeout:   orDefault(cfg.Timeout, DefaultTimeout),
+	}, nil
+}
+
+// orDefault returns value, or def when value is zero.
+func orDefault[T time.Duration | int](value, def T) T {
+	if value == 0 {
+		return def
+	}
+	return value
+}
@@ -0,0 +1,83 @@
+package httpclient
+
+import (
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// slowServer answers after delay, or when the client gives up.
+func slowServer(t *testing.T, delay time.Duration) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-time.After(delay):
+		case <-r.Context().Done():
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestNewHTTPClientTimesOutOnSlowEndpoint(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  NewHTTPClientConfig
+	}{
+		{"response header timeout", NewHTTPClientConfig{ResponseHeaderTimeout: 100 * time.Millisecond}},
+		{"request timeout", NewHTTPClientConfig{Timeout: 100 * time.Millisecond}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := slowServer(t, 5*time.Second)
+			client, err := NewHTTPClient(tt.cfg)
+			if err != nil {
+				t.Fatalf("NewHTTPClient() error = %v", err)
+			}
+
+			start := time.Now()
+			resp, err := client.Get(srv.URL)
+			if err == nil {
+				resp.Body.Close()
+				t.Fatal("Get() error = nil, want a timeout")
+			}
+			var netErr net.Error
+			if !errors.As(err, &netErr) || !netErr.Timeout() {
+				t.Errorf("Get() error = %v, want a timeout error", err)
+			}
+			if elapsed := time.Since(start); elapsed > 2*time.Second {
+				t.Errorf("Get() took %v, want it to give up after the timeout", elapsed)
+			}
+		})
+	}
+}
+
+func TestNewHTTPClientDefaults(t *testing.T) {
+	client, err := NewHTTPClient(NewHTTPClientConfig{})
+	if err != nil {
+		t.Fatalf("NewHTTPClient() error = %v", err)
+	}
+	if client.Timeout != DefaultTimeout {
+		t.Errorf("Timeout = %v, want %v", client.Timeout, DefaultTimeout)
+	}
+	transport := client.Transport.(*http.Transport)
+	if transport.ResponseHeaderTimeout != DefaultResponseHeaderTimeout || transport.MaxIdleConnsPerHost != DefaultMaxIdleConnsPerHost {
+		t.Errorf("transport = %+v, want the package defaults", transport)
+	}
+}
+
+func TestNewHTTPClientInvalidConfig(t *testing.T) {
+	for name, cfg := range map[string]NewHTTPClientConfig{
+		"negative timeout":  {Timeout: -time.Second},
+		"negative pool":     {MaxIdleConns: -1},
+		"relative proxy":    {ProxyURL: "proxy.internal:3128"},
+		"unparseable proxy": {ProxyURL: "::bad"},
+	} {
+		if _, err := NewHTTPClient(cfg); err == nil {
+			t.Errorf("%s: NewHTTPClient() error = nil, want an error", name)
+		}
+	}
+}
@@ -0,0 +1,111 @@
+// Package lifecycle coordinates the ordered shutdown of application services.
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+)
+
+// Hook releases a resource during shutdown. It should return promptly once ctx is done.
+type Hook func(ctx context.Context) error
+
+type namedHook struct {
+	name string
+	hook Hook
+}
+
+// Manager runs registered shutdown hooks in reverse order of registration,
+// so resources are released in the opposite order they were acquired.
+type Manager struct {
+	mu    sync.Mutex
+	hooks []namedHook
+	done  bool
+}
+
+// NewManager creates a new Manager.
+func NewManager() *Manager {
+	return &Manager{}
+}
+
+// Register adds a shutdown hook. Hooks registered after Shutdown has started are ignored.
+func (m *Manager) Register(name string, hook Hook) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.done {
+		log.Printf("Shutdown already started, ignoring hook %s", name)
+		return
+	}
+	m.hooks = append(m.hooks, namedHook{name: name, hook: hook})
+}
+
+// Shutdown runs every registered hook in reverse order of registration.
+// A hook that fails does not stop the others. Once ctx is done, the hook in progress
+// is abandoned and the remaining hooks are skipped. All failures are returned joined.
+// Shutdown only runs once; later calls return nil.
+func (m *Manager) Shutdown(ctx context.Context) error {
+	m.mu.Lock()
+	if m.done {
+		m.mu.Unlock()
+		return nil
+	}
+	m.done = true
+	hooks := m.hooks
+	m.mu.Unlock()
+
+	var errs []error
+	for i := len(hooks) - 1; i >= 0; i-- {
+		h := hooks[i]
+		if err := ctx.Err(); err != nil {
+			log.Printf("Skipping shutdown hook %s: %v", h.name, err)
+			errs = append(errs, fmt.Errorf("%s: skipped: %w", h.name, err))
+			continue
+		}
+
+		log.Printf("Running shutdown hook %s...", h.name)
+		if err := runHook(ctx, h.hook); err != nil {
+			log.Printf("Shutdown hook %s failed: %v", h.name, err)
+			errs = append(errs, fmt.Errorf("%s: %w", h.name, err))
+			continue
+		}
+		log.Printf("Shutdown hook %s completed", h.name)
+	}
+	return errors.Join(errs...)
+}
+
+// runHook runs hook and waits for it to finish or for ctx to be done, whichever comes first.
+func runHook(ctx context.Context, hook Hook) error {
+	done := make(chan error, 1)
+	go func() {
+		done <- hook(ctx)
+	}()
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Delay returns a Hook that waits for d, or until ctx is done. Registered right after a
+// server's hook, it runs just before it, so load balancers have time to see a failing
+// readiness probe and stop routing traffic before the server stops accepting connections.
+// The wait counts against the shutdown deadline.
+func Delay(d time.Duration) Hook {
+	return func(ctx context.Context) error {
+		if d <= 0 {
+			return nil
+		}
+		timer := time.NewTimer(d)
+		defer timer.Stop()
+		select {
+		case <-timer.C:
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
@@ -0,0 +1,148 @@
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// recorder collects the names of the hooks that ran, in order.
+type recorder struct {
+	mu  sync.Mutex
+	ran []string
+}
+
+func (r *recorder) hook(name string, err error) Hook {
+	return func(ctx context.Context) error {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		r.ran = append(r.ran, name)
+		return err
+	}
+}
+
+func (r *recorder) order() string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return strings.Join(r.ran, ",")
+}
+
+func TestShutdownRunsHooksInReverseOrder(t *testing.T) {
+	var rec recorder
+	m := NewManager()
+	m.Register("redis", rec.hook("redis", nil))
+	m.Register("firestore", rec.hook("firestore", nil))
+	m.Register("http-server", rec.hook("http-server", nil))
+
+	if err := m.Shutdown(context.Background()); err != nil {
+		t.Fatalf("Shutdown() error = %v", err)
+	}
+	if got, want := rec.order(), "http-server,firestore,redis"; got != want {
+		t.Errorf("hooks ran in order %q, want %q", got, want)
+	}
+}
+
+func TestShutdownContinuesAfterFailingHook(t *testing.T) {
+	var rec recorder
+	closeErr := errors.New("close failed")
+	m := NewManager()
+	m.Register("redis", rec.hook("redis", nil))
+	m.Register("firestore", rec.hook("firestore", closeErr))
+	m.Register("rabbitmq", rec.hook("rabbitmq", nil))
+
+	err := m.Shutdown(context.Background())
+	if !errors.Is(err, closeErr) || !strings.Contains(err.Error(), "firestore") {
+		t.Errorf("Shutdown() error = %v, want the firestore failure", err)
+	}
+	if got, want := rec.order(), "rabbitmq,firestore,redis"; got != want {
+		t.Errorf("hooks ran in order %q, want %q", got, want)
+	}
+}
+
+func TestShutdownAbandonsHookPastDeadline(t *testing.T) {
+	var rec recorder
+	release := make(chan struct{})
+	defer close(release)
+
+	m := NewManager()
+	m.Register("redis", rec.hook("redis", nil))
+	m.Register("stuck", func(ctx context.Context) error {
+		<-release // Ignores ctx, like a hung client.
+		return nil
+	})
+	m.Register("http-server", rec.hook("http-server", nil))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	err := m.Shutdown(ctx)
+
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("Shutdown() took %v, want it to return at the deadline", elapsed)
+	}
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Shutdown() error = %v, want DeadlineExceeded", err)
+	}
+	if !strings.Contains(err.Error(), "stuck") || !strings.Contains(err.Error(), "redis: skipped") {
+		t.Errorf("Shutdown() error = %v, want the stuck hook abandoned and redis skipped", err)
+	}
+	if got, want := rec.order(), "http-server"; got != want {
+		t.Errorf("hooks ran %q, want %q", got, want)
+	}
+}
+
+func TestShutdownRunsOnce(t *testing.T) {
+	var rec recorder
+	m := NewManager()
+	m.Register("redis", rec.hook("redis", errors.New("close failed")))
+
+	if err := m.Shutdown(context.Background()); err == nil {
+		t.Fatal("first Shutdown() error = nil, want the hook failure")
+	}
+	if err := m.Shutdown(context.Background()); err != nil {
+		t.Errorf("second Shutdown() error = %v, want nil", err)
+	}
+	m.Register("late", rec.hook("late", nil))
+	if got, want := rec.order(), "redis"; got != want {
+		t.Errorf("hooks ran %q, want %q", got, want)
+	}
+}
+
+func TestDelayRunsBeforeEarlierHooks(t *testing.T) {
+	const delay = 50 * time.Millisecond
+	var serverStopped time.Duration
+	start := time.Now()
+	m := NewManager()
+	m.Register("http-server", func(ctx context.Context) error {
+		serverStopped = time.Since(start)
+		return nil
+	})
+	m.Register("drain", Delay(delay))
+
+	if err := m.Shutdown(context.Background()); err != nil {
+		t.Fatalf("Shutdown() error = %v", err)
+	}
+	if serverStopped < delay {
+		t.Errorf("http-server hook ran after %v, want at least %v", serverStopped, delay)
+	}
+}
+
+func TestDelayIsBoundedByContext(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	err := Delay(time.Hour)(ctx)
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("Delay() took %v, want it to return at the deadline", elapsed)
+	}
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Delay() error = %v, want DeadlineExceeded", err)
+	}
+	if err := Delay(0)(context.Background()); err != nil {
+		t.Errorf("Delay(0) error = %v, want nil", err)
+	}
+}
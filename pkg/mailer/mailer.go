@@ -9,10 +9,14 @@ package mailer
 
 import (
 	"fmt"
+	"net"
 	"net/smtp"
 	"strings"
 )
 
+// DefaultSMTPAddr is the SMTP server (host:port) used by SendEmail.
+const DefaultSMTPAddr = "smtp.mailtrap.io:2525"
+
 // SendEmail sends an email using Mailtrap's SMTP server.
 //
 // It requires valid Mailtrap credentials (username and password) to authenticate with the SMTP server.
@@ -33,15 +37,21 @@ import (
 // Returns:
 //   An error if any of the following occurs:
 //     - Any of the required parameters (recipient, sender, subject, smtpUser, smtpPass) are empty.
-//     - Connection to the SMTP server (smtp.mailtrap.io:2525) fails.
+//     - Connection to the SMTP server (DefaultSMTPAddr) fails.
 //     - SMTP authentication fails (e.g., incorrect smtpUser or smtpPass).
 //     - The email sending command fails on the server.
 //   If the email is sent successfully, it returns nil.
 func SendEmail(recipient, sender, subject, body, smtpUser, smtpPass string) error {
+	return SendEmailVia(DefaultSMTPAddr, recipient, sender, subject, body, smtpUser, smtpPass)
+}
+
+// SendEmailVia works like SendEmail but sends through the SMTP server at smtpAddr (host:port).
+func SendEmailVia(smtpAddr, recipient, sender, subject, body, smtpUser, smtpPass string) error {
 	// SMTP server configuration
-	smtpHost := "smtp.mailtrap.io"
-	smtpPort := "2525"
-	smtpAddr := smtpHost + ":" + smtpPort
+	smtpHost, _, err := net.SplitHostPort(smtpAddr)
+	if err != nil {
+		return fmt.Errorf("invalid SMTP address %q: %w", smtpAddr, err)
+	}
 
 	// Basic validation
 	if recipient == "" {
@@ -76,7 +86,7 @@ func SendEmail(recipient, sender, subject, body, smtpUser, smtpPass string) erro
 	auth := smtp.PlainAuth("", smtpUser, smtpPass, smtpHost)
 
 	// Sending the email
-	err := smtp.SendMail(smtpAddr, auth, sender, []string{recipient}, message)
+	err = smtp.SendMail(smtpAddr, auth, sender, []string{recipient}, message)
 	if err != nil {
 		return fmt.Errorf("failed to send email: %w", err)
 	}
@@ -0,0 +1,121 @@
+package mailer
+
+import (
+	"encoding/base64"
+	"net"
+	"net/textproto"
+	"strings"
+	"testing"
+)
+
+// receivedEmail captures what a fakeSMTPServer was sent over one connection.
+type receivedEmail struct {
+	auth string // Decoded AUTH PLAIN credentials ("\x00user\x00pass").
+	from string
+	rcpt []string
+	data string
+}
+
+// fakeSMTPServer accepts a single SMTP session on a local listener and reports what it
+// received. It advertises AUTH PLAIN and no STARTTLS, which net/smtp allows on localhost.
+func fakeSMTPServer(t *testing.T) (addr string, received <-chan receivedEmail) {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listen() error = %v", err)
+	}
+	t.Cleanup(func() { ln.Close() })
+
+	ch := make(chan receivedEmail, 1)
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		tp := textproto.NewConn(conn)
+		var got receivedEmail
+		defer func() { ch <- got }()
+
+		tp.PrintfLine("220 localhost ESMTP")
+		for {
+			line, err := tp.ReadLine()
+			if err != nil {
+				return
+			}
+			verb := strings.ToUpper(strings.SplitN(line, " ", 2)[0])
+			switch verb {
+			case "EHLO", "HELO":
+				tp.PrintfLine("250-localhost")
+				tp.PrintfLine("250 AUTH PLAIN")
+			case "AUTH":
+				fields := strings.Fields(line)
+				if decoded, err := base64.StdEncoding.DecodeString(fields[len(fields)-1]); err == nil {
+					got.auth = string(decoded)
+				}
+				tp.PrintfLine("235 2.7.0 Authentication successful")
+			case "MAIL":
+				got.from = line
+				tp.PrintfLine("250 OK")
+			case "RCPT":
+				got.rcpt = append(got.rcpt, line)
+				tp.PrintfLine("250 OK")
+			case "DATA":
+				tp.PrintfLine("354 End data with <CR><LF>.<CR><LF>")
+				lines, err := tp.ReadDotLines()
+				if err != nil {
+					return
+				}
+				got.data = strings.Join(lines, "\n")
+				tp.PrintfLine("250 OK")
+			case "QUIT":
+				tp.PrintfLine("221 Bye")
+				return
+			default:
+				tp.PrintfLine("250 OK")
+			}
+		}
+	}()
+	return ln.Addr().String(), ch
+}
+
+func TestTemplateMailerSendsThroughConfiguredSMTPAddr(t *testing.T) {
+	addr, received := fakeSMTPServer(t)
+	m, err := NewTemplateMailer(NewTemplateMailerConfig{
+		From:          "noreply@example.com",
+		SubjectPrefix: "[Vaultify] ",
+		SMTPAddr:      addr,
+		SMTPUser:      "user",
+		SMTPPass:      "pass",
+	})
+	if err != nil {
+		t.Fatalf("NewTemplateMailer() error = %v", err)
+	}
+
+	if err := m.SendTemplate("test_email", map[string]interface{}{"SentAt": "now"}, "ops@example.com"); err != nil {
+		t.Fatalf("SendTemplate() error = %v", err)
+	}
+
+	got := <-received
+	if got.auth != "\x00user\x00pass" {
+		t.Errorf("AUTH credentials = %q, want user/pass", got.auth)
+	}
+	if got.from != "MAIL FROM:<noreply@example.com>" {
+		t.Errorf("MAIL = %q, want the configured sender", got.from)
+	}
+	if len(got.rcpt) != 1 || got.rcpt[0] != "RCPT TO:<ops@example.com>" {
+		t.Errorf("RCPT = %v, want the recipient", got.rcpt)
+	}
+	for _, want := range []string{"To: ops@example.com", "Subject: [Vaultify] SMTP configuration test"} {
+		if !strings.Contains(got.data, want) {
+			t.Errorf("message missing %q:\n%s", want, got.data)
+		}
+	}
+}
+
+func TestSendEmailViaInvalidAddress(t *testing.T) {
+	err := SendEmailVia("smtp.example.com", "to@example.com", "from@example.com", "subject", "body", "user", "pass")
+	if err == nil || !strings.Contains(err.Error(), "invalid SMTP address") {
+		t.Errorf("SendEmailVia() error = %v, want an invalid SMTP address error", err)
+	}
+}
@@ -0,0 +1,133 @@
+package mailer
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	"html"
+	"html/template"
+	"io/fs"
+	"path"
+	"strings"
+)
+
+// templateFS holds the email templates shipped with the binary.
+// Each file is named "<template name>.html". The file body is the email body, and it
+// must also define a "subject" block, e.g. {{define "subject"}}Vault shared{{end}}.
+//
+//go:embed templates/*.html
+var templateFS embed.FS
+
+// Mailer defines the interface for sending templated emails.
+type Mailer interface {
+	SendTemplate(name string, data interface{}, recipient string) error
+}
+
+// TemplateMailer implements the Mailer interface by rendering embedded html/template
+// files and sending them through SendEmailVia.
+type TemplateMailer struct {
+	from          string
+	subjectPrefix string
+	smtpUser      string
+	smtpPass      string
+	templates     map[string]*template.Template
+	send          func(recipient, sender, subject, body, smtpUser, smtpPass string) error
+}
+
+// NewTemplateMailerConfig contains options for creating a new TemplateMailer.
+type NewTemplateMailerConfig struct {
+	From          string // Sender address used for every email.
+	SubjectPrefix string // Prepended to every rendered subject, e.g. "[Vaultify] ".
+	SMTPAddr      string // SMTP server as host:port. Empty uses DefaultSMTPAddr.
+	SMTPUser      string
+	SMTPPass      string
+	// Sender delivers the rendered email. Nil uses SendEmailVia with SMTPAddr; tests can inject a stub.
+	Sender func(recipient, sender, subject, body, smtpUser, smtpPass string) error
+}
+
+// NewTemplateMailer creates a new TemplateMailer and parses all embedded templates.
+// It fails if the sender is missing or any template is invalid, so broken templates
+// surface at startup rather than when the first email is sent.
+func NewTemplateMailer(cfg NewTemplateMailerConfig) (Mailer, error) {
+	if cfg.From == "" {
+		return nil, fmt.Errorf("sender email address cannot be empty")
+	}
+
+	templates, err := parseTemplates(templateFS)
+	if err != nil {
+		return nil, err
+	}
+
+	send := cfg.Sender
+	if send == nil {
+		smtpAddr := cfg.SMTPAddr
+		if smtpAddr == "" {
+			smtpAddr = DefaultSMTPAddr
+		}
+		send = func(recipient, sender, subject, body, smtpUser, smtpPass string) error {
+			return SendEmailVia(smtpAddr, recipient, sender, subject, body, smtpUser, smtpPass)
+		}
+	}
+
+	return &TemplateMailer{
+		from:          cfg.From,
+		subjectPrefix: cfg.SubjectPrefix,
+		smtpUser:      cfg.SMTPUser,
+		smtpPass:      cfg.SMTPPass,
+		templates:     templates,
+		send:          send,
+	}, nil
+}
+
+// SendTemplate renders the named template with data and emails it to recipient.
+func (m *TemplateMailer) SendTemplate(name string, data interface{}, recipient string) error {
+	subject, body, err := m.render(name, data)
+	if err != nil {
+		return err
+	}
+	return m.send(recipient, m.from, m.subjectPrefix+subject, body, m.smtpUser, m.smtpPass)
+}
+
+// render executes the named template, returning the subject and HTML body.
+func (m *TemplateMailer) render(name string, data interface{}) (string, string, error) {
+	tmpl, ok := m.templates[name]
+	if !ok {
+		return "", "", fmt.Errorf("email template %q not found", name)
+	}
+
+	var subject bytes.Buffer
+	if err := tmpl.ExecuteTemplate(&subject, "subject", data); err != nil {
+		return "", "", fmt.Errorf("failed to render subject of email template %q: %w", name, err)
+	}
+
+	var body bytes.Buffer
+	if err := tmpl.Execute(&body, data); err != nil {
+		return "", "", fmt.Errorf("failed to render email template %q: %w", name, err)
+	}
+
+	// The subject is a header, not HTML: undo the escaping html/template applied
+	// and collapse whitespace so it fits on a single line.
+	return strings.Join(strings.Fields(html.UnescapeString(subject.String())), " "), body.String(), nil
+}
+
+// parseTemplates parses every template in fsys into its own set, keyed by file name without extension.
+func parseTemplates(fsys fs.FS) (map[string]*template.Template, error) {
+	files, err := fs.Glob(fsys, "templates/*.html")
+	if err != nil {
+		return nil, err
+	}
+
+	templates := make(map[string]*template.Template, len(files))
+	for _, file := range files {
+		name := strings.TrimSuffix(path.Base(file), ".html")
+		tmpl, err := template.New(path.Base(file)).ParseFS(fsys, file)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse email template %q: %w", name, err)
+		}
+		if tmpl.Lookup("subject") == nil {
+			return nil, fmt.Errorf("email template %q does not define a subject", name)
+		}
+		templates[name] = tmpl
+	}
+	return templates, nil
+}
@@ -0,0 +1,96 @@
+package mailer
+
+import (
+	"strings"
+	"testing"
+)
+
+// sentEmail captures what TemplateMailer handed to its sender.
+type sentEmail struct {
+	recipient, sender, subject, body string
+}
+
+func newTestMailer(t *testing.T, sent *sentEmail) Mailer {
+	t.Helper()
+	m, err := NewTemplateMailer(NewTemplateMailerConfig{
+		From:          "noreply@example.com",
+		SubjectPrefix: "[Vaultify] ",
+		Sender: func(recipient, sender, subject, body, smtpUser, smtpPass string) error {
+			*sent = sentEmail{recipient, sender, subject, body}
+			return nil
+		},
+	})
+	if err != nil {
+		t.Fatalf("NewTemplateMailer() error = %v", err)
+	}
+	return m
+}
+
+func TestSendTemplateRendersShareNotification(t *testing.T) {
+	var sent sentEmail
+	m := newTestMailer(t, &sent)
+
+	err := m.SendTemplate("share_notification", map[string]string{
+		"RecipientName":   "Bob",
+		"OwnerName":       "Alice",
+		"VaultName":       "Production",
+		"PermissionLevel": "read",
+		"VaultURL":        "https://app.example.com/vaults/v1",
+	}, "bob@example.com")
+	if err != nil {
+		t.Fatalf("SendTemplate() error = %v", err)
+	}
+
+	if sent.recipient != "bob@example.com" || sent.sender != "noreply@example.com" {
+		t.Errorf("sent to %q from %q", sent.recipient, sent.sender)
+	}
+	if want := `[Vaultify] Alice shared "Production" with you`; sent.subject != want {
+		t.Errorf("subject = %q, want %q", sent.subject, want)
+	}
+	for _, want := range []string{
+		"Hello Bob,",
+		"<strong>Alice</strong> shared the vault <strong>Production</strong>",
+		"(read access)",
+		`<a href="https://app.example.com/vaults/v1">`,
+	} {
+		if !strings.Contains(sent.body, want) {
+			t.Errorf("body missing %q:\n%s", want, sent.body)
+		}
+	}
+}
+
+func TestSendTemplateSubjectIsSingleUnescapedLine(t *testing.T) {
+	var sent sentEmail
+	m := newTestMailer(t, &sent)
+
+	err := m.SendTemplate("share_notification", map[string]string{
+		"OwnerName": "Tom & Jerry\r\nBcc: attacker@example.com",
+		"VaultName": "<Keys>",
+	}, "bob@example.com")
+	if err != nil {
+		t.Fatalf("SendTemplate() error = %v", err)
+	}
+
+	if strings.ContainsAny(sent.subject, "\r\n") {
+		t.Errorf("subject %q spans several lines", sent.subject)
+	}
+	if want := `[Vaultify] Tom & Jerry Bcc: attacker@example.com shared "<Keys>" with you`; sent.subject != want {
+		t.Errorf("subject = %q, want %q", sent.subject, want)
+	}
+	// The body is HTML and stays escaped.
+	if !strings.Contains(sent.body, "Tom &amp; Jerry") || !strings.Contains(sent.body, "&lt;Keys&gt;") {
+		t.Errorf("body is not HTML-escaped:\n%s", sent.body)
+	}
+}
+
+func TestSendTemplateUnknownTemplate(t *testing.T) {
+	var sent sentEmail
+	m := newTestMailer(t, &sent)
+
+	if err := m.SendTemplate("does_not_exist", nil, "bob@example.com"); err == nil {
+		t.Error("SendTemplate() with an unknown template returned nil error")
+	}
+	if sent.recipient != "" {
+		t.Error("an email was sent for an unknown template")
+	}
+}